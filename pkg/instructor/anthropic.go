@@ -11,6 +11,7 @@ type InstructorAnthropic struct {
 	mode       Mode
 	maxRetries int
 	validate   bool
+	opts       Options
 }
 
 var _ Instructor = &InstructorAnthropic{}
@@ -22,10 +23,11 @@ func FromAnthropic(client *anthropic.Client, opts ...Options) *InstructorAnthrop
 	i := &InstructorAnthropic{
 		Client: client,
 
-		provider:   ProviderOpenAI,
+		provider:   ProviderAnthropic,
 		mode:       *options.Mode,
 		maxRetries: *options.MaxRetries,
 		validate:   *options.validate,
+		opts:       options,
 	}
 	return i
 }
@@ -44,3 +46,11 @@ func (i *InstructorAnthropic) Provider() string {
 func (i *InstructorAnthropic) Validate() bool {
 	return i.validate
 }
+
+func (i *InstructorAnthropic) options() Options {
+	return i.opts
+}
+
+func (i *InstructorAnthropic) promptCaching() bool {
+	return i.opts.promptCaching != nil && *i.opts.promptCaching
+}
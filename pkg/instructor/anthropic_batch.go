@@ -0,0 +1,243 @@
+package instructor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	anthropic "github.com/liushuangls/go-anthropic/v2"
+)
+
+// AnthropicBatchRequest is an extraction request of an Anthropic Message Batch.
+type AnthropicBatchRequest struct {
+	// CustomID identifies the request in the batch, and must be unique in it.
+	CustomID string
+	Request  anthropic.MessagesRequest
+}
+
+// AnthropicBatchResult is the result of an extraction of an Anthropic Message
+// Batch. A request that errored, was canceled, expired, returned an invalid
+// response or has no result sets Err, and Response when the provider responded.
+type AnthropicBatchResult[T any] struct {
+	CustomID string
+	Value    *T
+	Response *anthropic.MessagesResponse
+	Usage    Usage
+	Err      error
+}
+
+// NewAnthropicBatch returns the Message Batch of requests extracting a T, with
+// the tools or JSON schema instructions of the mode of i added to every request.
+func NewAnthropicBatch[T any](i *InstructorAnthropic, requests []AnthropicBatchRequest) (anthropic.BatchRequest, error) {
+	var batch anthropic.BatchRequest
+
+	schema, err := NewSchema(reflect.TypeOf(new(T)))
+	if err != nil {
+		return batch, err
+	}
+
+	ids := make(map[string]bool, len(requests))
+	for _, r := range requests {
+		if r.CustomID == "" || ids[r.CustomID] {
+			return batch, fmt.Errorf("batch request custom ID %q is empty or not unique", r.CustomID)
+		}
+		ids[r.CustomID] = true
+
+		req := r.Request
+		if req.Stream {
+			return batch, fmt.Errorf("batch request %q: streaming is not supported in batches", r.CustomID)
+		}
+		if err := i.injectSchema(&req, schema); err != nil {
+			return batch, err
+		}
+		batch.Requests = append(batch.Requests, anthropic.InnerRequests{CustomId: r.CustomID, Params: req})
+	}
+	return batch, nil
+}
+
+// SubmitAnthropicBatch creates a Message Batch of requests extracting a T:
+//
+//	batch, err := instructor.SubmitAnthropicBatch[Person](ctx, client, requests)
+//	batch, err = instructor.WaitAnthropicBatch(ctx, client, batch.Id, 0)
+//	results, err := instructor.AnthropicBatchResults[Person](ctx, client, batch.Id, requests)
+//	err = instructor.RetryAnthropicBatch(ctx, client, requests, results)
+func SubmitAnthropicBatch[T any](ctx context.Context, i *InstructorAnthropic, requests []AnthropicBatchRequest) (*anthropic.BatchResponse, error) {
+	batch, err := NewAnthropicBatch[T](i, requests)
+	if err != nil {
+		return nil, err
+	}
+	return i.Client.CreateBatch(ctx, batch)
+}
+
+// WaitAnthropicBatch polls the Message Batch batchID every interval,
+// DefaultBatchPollInterval when zero, until it ended.
+func WaitAnthropicBatch(ctx context.Context, i *InstructorAnthropic, batchID anthropic.BatchId, interval time.Duration) (*anthropic.BatchResponse, error) {
+	if interval <= 0 {
+		interval = DefaultBatchPollInterval
+	}
+
+	for {
+		resp, err := i.Client.RetrieveBatch(ctx, batchID)
+		if err != nil || resp.ProcessingStatus == anthropic.ProcessingStatusEnded {
+			return resp, err
+		}
+
+		if err := sleep(ctx, interval, nil); err != nil {
+			return resp, err
+		}
+	}
+}
+
+// anthropicBatchLine is a line of the results of a Message Batch.
+type anthropicBatchLine struct {
+	CustomID string `json:"custom_id"`
+	Result   struct {
+		Type    anthropic.ResultType        `json:"type"`
+		Message *anthropic.MessagesResponse `json:"message"`
+		Error   *anthropic.ErrorResponse    `json:"error"`
+	} `json:"result"`
+}
+
+// AnthropicBatchResults retrieves the results of the ended Message Batch
+// batchID, decoding and validating a T from every response. Results are in the
+// order of requests. The Anthropic client reads the whole results file in
+// memory and fails on any result it cannot decode: ReadAnthropicBatchResults
+// decodes the file as it is downloaded instead.
+func AnthropicBatchResults[T any](ctx context.Context, i *InstructorAnthropic, batchID anthropic.BatchId, requests []AnthropicBatchRequest) ([]AnthropicBatchResult[T], error) {
+	resp, err := i.Client.RetrieveBatchResults(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	return ReadAnthropicBatchResults[T](i, bytes.NewReader(resp.RawResponse), requests)
+}
+
+// ReadAnthropicBatchResults reads the results file of a Message Batch from r,
+// such as the body of a download of its results URL, decoding and validating a
+// T from every response. Results are in the order of requests.
+func ReadAnthropicBatchResults[T any](i *InstructorAnthropic, r io.Reader, requests []AnthropicBatchRequest) ([]AnthropicBatchResult[T], error) {
+	schema, err := NewSchema(reflect.TypeOf(new(T)))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]AnthropicBatchResult[T], len(requests))
+	index := make(map[string]int, len(requests))
+	for k, req := range requests {
+		index[req.CustomID] = k
+		results[k] = AnthropicBatchResult[T]{
+			CustomID: req.CustomID,
+			Err:      fmt.Errorf("request %q has no result in the batch", req.CustomID),
+		}
+	}
+
+	dec := json.NewDecoder(r)
+	for {
+		var line json.RawMessage
+		err := dec.Decode(&line)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return results, fmt.Errorf("reading batch results: %w", err)
+		}
+
+		result := anthropicBatchResult[T](i, schema, line)
+		if k, ok := index[result.CustomID]; ok {
+			results[k] = result
+		}
+	}
+	return results, nil
+}
+
+func anthropicBatchResult[T any](i *InstructorAnthropic, schema *Schema, raw []byte) AnthropicBatchResult[T] {
+	var line anthropicBatchLine
+	if err := json.Unmarshal(raw, &line); err != nil {
+		// keep the ID of a result that does not decode
+		var id struct {
+			CustomID string `json:"custom_id"`
+		}
+		_ = json.Unmarshal(raw, &id)
+		return AnthropicBatchResult[T]{CustomID: id.CustomID, Err: fmt.Errorf("reading batch result %q: %w", id.CustomID, err)}
+	}
+
+	result := AnthropicBatchResult[T]{CustomID: line.CustomID}
+
+	switch line.Result.Type {
+	case anthropic.ResultTypeSucceeded:
+	case anthropic.ResultTypeErrored:
+		if line.Result.Error != nil && line.Result.Error.Error != nil {
+			result.Err = line.Result.Error.Error
+		} else {
+			result.Err = fmt.Errorf("batch request %q errored", line.CustomID)
+		}
+		return result
+	default:
+		result.Err = fmt.Errorf("batch request %q %s", line.CustomID, line.Result.Type)
+		return result
+	}
+
+	resp := line.Result.Message
+	if resp == nil {
+		result.Err = fmt.Errorf("batch request %q has no message", line.CustomID)
+		return result
+	}
+	result.Response = resp
+	result.Usage = *i.countUsageFromResponse(resp, &Usage{})
+
+	text, err := i.output(resp, schema)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	text = extractJSON(&text)
+	value := new(T)
+	if err := json.Unmarshal([]byte(text), value); err != nil {
+		result.Err = err
+		return result
+	}
+	if i.Validate() {
+		if err := validate.Struct(value); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+
+	result.Value = value
+	return result
+}
+
+// RetryAnthropicBatch extracts the failed results of a Message Batch
+// synchronously, replacing them with the results of the retries. results are
+// matched to requests by CustomID.
+func RetryAnthropicBatch[T any](ctx context.Context, i *InstructorAnthropic, requests []AnthropicBatchRequest, results []AnthropicBatchResult[T]) error {
+	index := make(map[string]int, len(requests))
+	for k, r := range requests {
+		index[r.CustomID] = k
+	}
+	for _, r := range results {
+		if _, ok := index[r.CustomID]; r.Err != nil && !ok {
+			return fmt.Errorf("batch result %q has no request", r.CustomID)
+		}
+	}
+
+	for k := range results {
+		if results[k].Err == nil {
+			continue
+		}
+
+		value := new(T)
+		resp, err := i.CreateMessages(ctx, requests[index[results[k].CustomID]].Request, value)
+		results[k] = AnthropicBatchResult[T]{CustomID: results[k].CustomID, Usage: *i.countUsageFromResponse(&resp, &Usage{}), Err: err}
+		if err == nil {
+			results[k].Value = value
+			results[k].Response = &resp
+		}
+	}
+	return nil
+}
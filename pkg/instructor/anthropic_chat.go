@@ -2,9 +2,12 @@ package instructor
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"unicode"
 
 	anthropic "github.com/liushuangls/go-anthropic/v2"
 )
@@ -24,6 +27,52 @@ func (i *InstructorAnthropic) CreateMessages(ctx context.Context, request anthro
 	return response, nil
 }
 
+func (i *InstructorAnthropic) Extract(ctx context.Context, request Request, responseType any) (*Response, error) {
+	return extract(i, ctx, request, responseType)
+}
+
+func (i *InstructorAnthropic) nativeRequest(request Request) (interface{}, error) {
+	req := anthropic.MessagesRequest{
+		Model:       anthropic.Model(request.Model),
+		System:      request.system(),
+		MaxTokens:   request.maxTokens(),
+		Temperature: request.Temperature,
+	}
+
+	for _, m := range request.Messages {
+		var role anthropic.ChatRole
+		switch m.Role {
+		case RoleSystem:
+			continue
+		case RoleUser:
+			role = anthropic.RoleUser
+		case RoleAssistant:
+			role = anthropic.RoleAssistant
+		default:
+			return nil, fmt.Errorf("role %q is not supported for %s", m.Role, i.Provider())
+		}
+
+		msg := anthropic.Message{Role: role}
+		for _, img := range m.Images {
+			if err := img.validate(); err != nil {
+				return nil, err
+			}
+			if len(img.Data) == 0 {
+				return nil, fmt.Errorf("image URLs are not supported for %s; use image data", i.Provider())
+			}
+			msg.Content = append(msg.Content, anthropic.NewImageMessageContent(
+				anthropic.NewMessageContentSource(anthropic.MessagesContentSourceTypeBase64, img.MediaType, base64.StdEncoding.EncodeToString(img.Data)),
+			))
+		}
+		if m.Content != "" {
+			msg.Content = append(msg.Content, anthropic.NewTextMessageContent(m.Content))
+		}
+		req.Messages = append(req.Messages, msg)
+	}
+
+	return req, nil
+}
+
 func (i *InstructorAnthropic) chat(ctx context.Context, request interface{}, schema *Schema) (string, interface{}, error) {
 
 	req, ok := request.(anthropic.MessagesRequest)
@@ -38,6 +87,8 @@ func (i *InstructorAnthropic) chat(ctx context.Context, request interface{}, sch
 	switch i.Mode() {
 	case ModeToolCall:
 		return i.completionToolCall(ctx, &req, schema)
+	case ModeJSON:
+		return i.completionJSON(ctx, &req, schema)
 	case ModeJSONSchema:
 		return i.completionJSONSchema(ctx, &req, schema)
 	default:
@@ -47,6 +98,85 @@ func (i *InstructorAnthropic) chat(ctx context.Context, request interface{}, sch
 
 func (i *InstructorAnthropic) completionToolCall(ctx context.Context, request *anthropic.MessagesRequest, schema *Schema) (string, *anthropic.MessagesResponse, error) {
 
+	injectAnthropicTools(request, schema, i.promptCaching())
+
+	resp, err := i.Client.CreateMessages(ctx, *request)
+	if err != nil {
+		return "", nil, err
+	}
+
+	text, err := anthropicToolOutput(&resp)
+	if err != nil {
+		return "", nilAnthropicRespWithUsage(&resp), err
+	}
+	return text, &resp, nil
+}
+
+func (i *InstructorAnthropic) completionJSONSchema(ctx context.Context, request *anthropic.MessagesRequest, schema *Schema) (string, *anthropic.MessagesResponse, error) {
+
+	injectAnthropicJSONSchema(request, schema, i.promptCaching())
+
+	resp, err := i.Client.CreateMessages(ctx, *request)
+	if err != nil {
+		return "", nil, err
+	}
+
+	text, err := anthropicTextOutput(&resp)
+	if err != nil {
+		return "", nilAnthropicRespWithUsage(&resp), err
+	}
+	return text, &resp, nil
+}
+
+func (i *InstructorAnthropic) completionJSON(ctx context.Context, request *anthropic.MessagesRequest, schema *Schema) (string, *anthropic.MessagesResponse, error) {
+
+	injectAnthropicJSON(request, schema, i.promptCaching())
+
+	resp, err := i.Client.CreateMessages(ctx, *request)
+	if err != nil {
+		return "", nil, err
+	}
+
+	text, err := anthropicTextOutput(&resp)
+	if err != nil {
+		return "", nilAnthropicRespWithUsage(&resp), err
+	}
+	return stitchAnthropicPrefill(schema, &resp, text), &resp, nil
+}
+
+// injectSchema adds the instructions of the mode of i for schema to request,
+// as the completion methods of the mode do.
+func (i *InstructorAnthropic) injectSchema(request *anthropic.MessagesRequest, schema *Schema) error {
+	switch i.Mode() {
+	case ModeToolCall:
+		injectAnthropicTools(request, schema, i.promptCaching())
+	case ModeJSON:
+		injectAnthropicJSON(request, schema, i.promptCaching())
+	case ModeJSONSchema:
+		injectAnthropicJSONSchema(request, schema, i.promptCaching())
+	default:
+		return fmt.Errorf("mode '%s' is not supported for %s", i.Mode(), i.Provider())
+	}
+	return nil
+}
+
+// output returns the output of resp in the mode of i.
+func (i *InstructorAnthropic) output(resp *anthropic.MessagesResponse, schema *Schema) (string, error) {
+	switch i.Mode() {
+	case ModeToolCall:
+		return anthropicToolOutput(resp)
+	case ModeJSON:
+		text, err := anthropicTextOutput(resp)
+		if err != nil {
+			return "", err
+		}
+		return stitchAnthropicPrefill(schema, resp, text), nil
+	default:
+		return anthropicTextOutput(resp)
+	}
+}
+
+func injectAnthropicTools(request *anthropic.MessagesRequest, schema *Schema, cache bool) {
 	request.Tools = []anthropic.ToolDefinition{}
 
 	for _, function := range schema.Functions {
@@ -58,11 +188,13 @@ func (i *InstructorAnthropic) completionToolCall(ctx context.Context, request *a
 		request.Tools = append(request.Tools, t)
 	}
 
-	resp, err := i.Client.CreateMessages(ctx, *request)
-	if err != nil {
-		return "", nil, err
+	if cache && len(request.Tools) > 0 {
+		// the breakpoint on the last tool caches all of them
+		request.Tools[len(request.Tools)-1].CacheControl = &anthropic.MessageCacheControl{Type: anthropic.CacheControlTypeEphemeral}
 	}
+}
 
+func anthropicToolOutput(resp *anthropic.MessagesResponse) (string, error) {
 	for _, c := range resp.Content {
 		if c.Type != anthropic.MessagesContentTypeToolUse {
 			// Skip non tool responses
@@ -71,18 +203,16 @@ func (i *InstructorAnthropic) completionToolCall(ctx context.Context, request *a
 
 		toolInput, err := json.Marshal(c.Input)
 		if err != nil {
-			return "", nilAnthropicRespWithUsage(&resp), err
+			return "", err
 		}
 		// TODO: handle more than 1 tool use
-		return string(toolInput), &resp, nil
+		return string(toolInput), nil
 	}
 
-	return "", nilAnthropicRespWithUsage(&resp), errors.New("more than 1 tool response at a time is not implemented")
-
+	return "", errors.New("received no tool use from model, expected at least 1")
 }
 
-func (i *InstructorAnthropic) completionJSONSchema(ctx context.Context, request *anthropic.MessagesRequest, schema *Schema) (string, *anthropic.MessagesResponse, error) {
-
+func injectAnthropicJSONSchema(request *anthropic.MessagesRequest, schema *Schema, cache bool) {
 	system := fmt.Sprintf(`
 Please responsd with json in the following json_schema:
 
@@ -91,29 +221,241 @@ Please responsd with json in the following json_schema:
 Make sure to return an instance of the JSON, not the schema itself.
 `, schema.String)
 
+	// the system parts replace the system string when set
+	if cache || len(request.MultiSystem) > 0 {
+		if len(request.MultiSystem) == 0 && request.System != "" {
+			request.MultiSystem = anthropic.NewMultiSystemMessages(request.System)
+		}
+		part := anthropic.MessageSystemPart{Type: "text", Text: system}
+		if cache {
+			part.CacheControl = &anthropic.MessageCacheControl{Type: anthropic.CacheControlTypeEphemeral}
+		}
+		request.MultiSystem = append(request.MultiSystem[:len(request.MultiSystem):len(request.MultiSystem)], part)
+		return
+	}
+
 	if request.System == "" {
 		request.System = system
 	} else {
 		request.System += system
 	}
+}
 
-	resp, err := i.Client.CreateMessages(ctx, *request)
+// injectAnthropicJSON asks for the JSON of schema, prefilling the response
+// with the opening of the JSON object, or of the JSON array for slices.
+func injectAnthropicJSON(request *anthropic.MessagesRequest, schema *Schema, cache bool) {
+	injectAnthropicJSONSchema(request, schema, cache)
+
+	// extended thinking does not support prefilling the response
+	if anthropicThinking(request) {
+		return
+	}
+
+	// consecutive assistant messages are combined, so the prefill continues
+	// an assistant message the request may end with
+	request.Messages = append(request.Messages[:len(request.Messages):len(request.Messages)],
+		anthropic.NewAssistantTextMessage(anthropicPrefill(schema)))
+}
+
+// anthropicPrefill returns the start of the response prefilled in ModeJSON.
+func anthropicPrefill(schema *Schema) string {
+	if schema.Type == "array" {
+		return "["
+	}
+	return "{"
+}
+
+// stitchAnthropicPrefill returns the JSON of a ModeJSON response, whose text
+// continues the prefill.
+func stitchAnthropicPrefill(schema *Schema, resp *anthropic.MessagesResponse, text string) string {
+	if anthropicThought(resp) {
+		// the response was not prefilled, see injectAnthropicJSON
+		return text
+	}
+
+	prefill := anthropicPrefill(schema)
+	// an object cannot continue with another one, so the model repeated it
+	if prefill == "{" && strings.HasPrefix(strings.TrimSpace(text), prefill) {
+		return text
+	}
+	// the elements of an array start with one bracket less than a repeat
+	if prefill == "[" && leadingBrackets(text) >= arrayDepth(schema) {
+		return text
+	}
+	return prefill + text
+}
+
+// arrayDepth returns how many arrays nest at the root of schema.
+func arrayDepth(schema *Schema) int {
+	depth := 0
+	for s := schema.Schema; s != nil && s.Type == "array"; s = s.Items {
+		depth++
+	}
+	return depth
+}
+
+// leadingBrackets returns how many opening brackets text starts with,
+// whitespace aside.
+func leadingBrackets(text string) int {
+	n := 0
+	for _, r := range text {
+		switch {
+		case r == '[':
+			n++
+		case !unicode.IsSpace(r):
+			return n
+		}
+	}
+	return n
+}
+
+// anthropicThinking reports whether request enables extended thinking.
+func anthropicThinking(request *anthropic.MessagesRequest) bool {
+	return request.Thinking != nil && request.Thinking.Type == anthropic.ThinkingTypeEnabled
+}
+
+// anthropicThought reports whether resp has thinking blocks, redacted or not.
+func anthropicThought(resp *anthropic.MessagesResponse) bool {
+	for _, c := range resp.Content {
+		if c.Type == anthropic.MessagesContentTypeThinking || c.Type == anthropic.MessagesContentTypeRedactedThinking {
+			return true
+		}
+	}
+	return false
+}
+
+// reasoning returns the thinking blocks of the response, concatenated.
+// Redacted thinking is encrypted and left out.
+func (i *InstructorAnthropic) reasoning(response interface{}) string {
+	resp, ok := response.(*anthropic.MessagesResponse)
+	if !ok || resp == nil {
+		return ""
+	}
+
+	var reasoning strings.Builder
+	for _, c := range resp.Content {
+		if c.Type == anthropic.MessagesContentTypeThinking && c.MessageContentThinking != nil {
+			reasoning.WriteString(c.Thinking)
+		}
+	}
+	return reasoning.String()
+}
+
+// anthropicTextOutput returns the text blocks of resp, concatenated, skipping
+// the thinking blocks of extended thinking.
+func anthropicTextOutput(resp *anthropic.MessagesResponse) (string, error) {
+	var text strings.Builder
+	found := false
+	for _, c := range resp.Content {
+		if c.Type == anthropic.MessagesContentTypeText && c.Text != nil {
+			text.WriteString(*c.Text)
+			found = true
+		}
+	}
+
+	if !found {
+		return "", errors.New("received no text from model")
+	}
+	return text.String(), nil
+}
+
+func (i *InstructorAnthropic) requestModel(request interface{}) string {
+	req, ok := request.(anthropic.MessagesRequest)
+	if !ok {
+		return ""
+	}
+	return string(req.Model)
+}
+
+func (i *InstructorAnthropic) reaskRequest(request interface{}, model string, output string, err error) (interface{}, error) {
+	req, ok := request.(anthropic.MessagesRequest)
+	if !ok {
+		return nil, fmt.Errorf("invalid request type for %s client", i.Provider())
+	}
+	if model != "" {
+		req.Model = anthropic.Model(model)
+	}
+	req.Messages = append(req.Messages[:len(req.Messages):len(req.Messages)],
+		anthropic.NewAssistantTextMessage(output),
+		anthropic.NewUserTextMessage(reaskPrompt(err)),
+	)
+	return req, nil
+}
+
+func (i *InstructorAnthropic) continueChat(ctx context.Context, request interface{}, schema *Schema, output string) (string, interface{}, bool, error) {
+	req, ok := request.(anthropic.MessagesRequest)
+	// tool inputs cannot be continued, nor responses prefilled with thinking
+	if !ok || (i.Mode() != ModeJSON && i.Mode() != ModeJSONSchema) || anthropicThinking(&req) {
+		return "", nil, false, nil
+	}
+
+	injectAnthropicJSONSchema(&req, schema, i.promptCaching())
+
+	// the response is prefilled with the output, which cannot end with
+	// whitespace, e.g. within a string
+	prefill := strings.TrimRight(output, " \t\r\n")
+	req.Messages = append(req.Messages[:len(req.Messages):len(req.Messages)], anthropic.NewAssistantTextMessage(prefill))
+
+	resp, err := i.Client.CreateMessages(ctx, req)
 	if err != nil {
-		return "", nil, err
+		return "", nil, true, err
+	}
+
+	text, err := anthropicTextOutput(&resp)
+	if err != nil {
+		return "", nilAnthropicRespWithUsage(&resp), true, err
 	}
+	// the continuation may start with the whitespace left out of the prefill
+	return output + strings.TrimPrefix(text, output[len(prefill):]), &resp, true, nil
+}
 
-	text := resp.Content[0].Text
+func (i *InstructorAnthropic) raiseLimit(request interface{}, used int, ceiling int) (interface{}, bool) {
+	req, ok := request.(anthropic.MessagesRequest)
+	if !ok {
+		return nil, false
+	}
 
-	return *text, &resp, nil
+	raised, ok := raisedLimit(req.MaxTokens, used, ceiling)
+	req.MaxTokens = raised
+	return req, ok
 }
 
-func (i *InstructorAnthropic) emptyResponseWithUsageSum(usage *UsageSum) interface{} {
-	return &anthropic.MessagesResponse{
-		Usage: anthropic.MessagesUsage{
-			InputTokens:  usage.InputTokens,
-			OutputTokens: usage.OutputTokens,
-		},
+func (i *InstructorAnthropic) finishReason(response interface{}) string {
+	resp, ok := response.(*anthropic.MessagesResponse)
+	if !ok || resp == nil {
+		return ""
+	}
+	return string(resp.StopReason)
+}
+
+func (i *InstructorAnthropic) promptMessages(request interface{}) []promptMessage {
+	req, ok := request.(anthropic.MessagesRequest)
+	if !ok {
+		return nil
+	}
+
+	messages := make([]promptMessage, 0, len(req.Messages)+len(req.MultiSystem)+1)
+	// the system parts replace the system string when set, as when sent
+	if len(req.MultiSystem) == 0 && req.System != "" {
+		messages = append(messages, promptMessage{Role: "system", Content: req.System})
 	}
+	for _, part := range req.MultiSystem {
+		messages = append(messages, promptMessage{Role: "system", Content: part.Text})
+	}
+	for _, m := range req.Messages {
+		content := ""
+		for _, c := range m.Content {
+			content += c.GetText()
+		}
+		messages = append(messages, promptMessage{Role: string(m.Role), Content: content})
+	}
+	return messages
+}
+
+func (i *InstructorAnthropic) emptyResponseWithUsageSum(usage *Usage) interface{} {
+	resp := &anthropic.MessagesResponse{}
+	addAnthropicUsage(&resp.Usage, usage)
+	return resp
 }
 
 func (i *InstructorAnthropic) emptyResponseWithResponseUsage(response interface{}) interface{} {
@@ -123,40 +465,54 @@ func (i *InstructorAnthropic) emptyResponseWithResponseUsage(response interface{
 	}
 
 	return &anthropic.MessagesResponse{
-		Usage: resp.Usage,
+		StopReason: resp.StopReason,
+		Usage:      resp.Usage,
 	}
 }
 
-func (i *InstructorAnthropic) addUsageSumToResponse(response interface{}, usage *UsageSum) (interface{}, error) {
+func (i *InstructorAnthropic) addUsageSumToResponse(response interface{}, usage *Usage) (interface{}, error) {
 	resp, ok := response.(*anthropic.MessagesResponse)
 	if !ok {
 		return response, fmt.Errorf("internal type error: expected *anthropic.MessagesResponse, got %T", response)
 	}
 
-	resp.Usage.InputTokens += usage.InputTokens
-	resp.Usage.OutputTokens += usage.OutputTokens
+	addAnthropicUsage(&resp.Usage, usage)
 
 	return response, nil
 }
 
-func (i *InstructorAnthropic) countUsageFromResponse(response interface{}, usage *UsageSum) *UsageSum {
+func (i *InstructorAnthropic) countUsageFromResponse(response interface{}, usage *Usage) *Usage {
 	resp, ok := response.(*anthropic.MessagesResponse)
-	if !ok {
+	if !ok || resp == nil {
 		return usage
 	}
 
-	usage.InputTokens += resp.Usage.InputTokens
+	// Anthropic reports cache reads and writes separately from the input tokens.
+	input := resp.Usage.InputTokens + resp.Usage.CacheReadInputTokens + resp.Usage.CacheCreationInputTokens
+
+	usage.InputTokens += input
 	usage.OutputTokens += resp.Usage.OutputTokens
+	usage.TotalTokens += input + resp.Usage.OutputTokens
+	usage.CachedInputTokens += resp.Usage.CacheReadInputTokens
+	usage.CacheCreationTokens += resp.Usage.CacheCreationInputTokens
 
 	return usage
 }
 
+func addAnthropicUsage(native *anthropic.MessagesUsage, usage *Usage) {
+	native.InputTokens += usage.InputTokens - usage.CachedInputTokens - usage.CacheCreationTokens
+	native.OutputTokens += usage.OutputTokens
+	native.CacheReadInputTokens += usage.CachedInputTokens
+	native.CacheCreationInputTokens += usage.CacheCreationTokens
+}
+
 func nilAnthropicRespWithUsage(resp *anthropic.MessagesResponse) *anthropic.MessagesResponse {
 	if resp == nil {
 		return nil
 	}
 
 	return &anthropic.MessagesResponse{
-		Usage: resp.Usage,
+		StopReason: resp.StopReason,
+		Usage:      resp.Usage,
 	}
 }
@@ -0,0 +1,225 @@
+package instructor
+
+import (
+	"context"
+	"sync"
+)
+
+const DefaultBatchConcurrency = 8
+
+// BatchOptions configures a batch extraction.
+type BatchOptions struct {
+	// Concurrency is the number of extractions run at once, DefaultBatchConcurrency when zero.
+	Concurrency int
+	// RateLimit limits the requests and tokens sent per minute by the batch.
+	// Tokens are estimated from the request before it is sent, then corrected
+	// with the usage reported by the provider.
+	RateLimit RateLimit
+	// Progress is called after every extraction, from one goroutine at a time.
+	Progress func(BatchProgress)
+}
+
+// BatchProgress reports the progress of a batch extraction.
+type BatchProgress struct {
+	// Total is the number of requests, or -1 when they are read from a channel.
+	Total  int
+	Done   int
+	Failed int
+	Usage  Usage
+	Cost   Cost
+}
+
+// BatchResult is the result of a single extraction of a batch. A failed
+// extraction sets Err and does not abort the batch.
+type BatchResult[T any] struct {
+	// Index is the position of the request in the batch.
+	Index    int
+	Value    *T
+	Response *Response
+	Err      error
+}
+
+// ExtractBatch extracts a T from every request concurrently. Results are in
+// the order of requests:
+//
+//	results := instructor.ExtractBatch[Person](ctx, client, requests, instructor.BatchOptions{
+//		Concurrency: 16,
+//		RateLimit:   instructor.RateLimit{RequestsPerMinute: 500, TokensPerMinute: 200_000},
+//	})
+//
+//	for _, r := range results {
+//		if r.Err != nil {
+//			log.Printf("request %d: %v", r.Index, r.Err)
+//		}
+//	}
+func ExtractBatch[T any](ctx context.Context, e Extractor, requests []Request, opts BatchOptions) []BatchResult[T] {
+	in := make(chan Request)
+	go func() {
+		defer close(in)
+		for _, r := range requests {
+			select {
+			case in <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	results := make([]BatchResult[T], len(requests))
+	received := make([]bool, len(requests))
+	for r := range runBatch[T](ctx, e, in, len(requests), opts) {
+		results[r.Index] = r
+		received[r.Index] = true
+	}
+
+	// the batch stopped early once ctx was done
+	for k := range results {
+		if !received[k] {
+			results[k] = BatchResult[T]{Index: k, Err: ctx.Err()}
+		}
+	}
+	return results
+}
+
+// ExtractBatchStream extracts a T from every request read from requests
+// concurrently, until requests is closed. Results are sent in the order the
+// requests were read, and the returned channel is closed after the last one,
+// or once ctx is done: a consumer that stops reading must cancel ctx.
+func ExtractBatchStream[T any](ctx context.Context, e Extractor, requests <-chan Request, opts BatchOptions) <-chan BatchResult[T] {
+	return runBatch[T](ctx, e, requests, -1, opts)
+}
+
+type batchJob struct {
+	index   int
+	request Request
+}
+
+func runBatch[T any](ctx context.Context, e Extractor, requests <-chan Request, total int, opts BatchOptions) <-chan BatchResult[T] {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	limit := newLimiter(opts.RateLimit)
+
+	jobs := make(chan batchJob)
+	go func() {
+		defer close(jobs)
+		for index := 0; ; index++ {
+			var r Request
+			var ok bool
+			select {
+			case r, ok = <-requests:
+			case <-ctx.Done():
+				return
+			}
+			if !ok {
+				return
+			}
+
+			select {
+			case jobs <- batchJob{index: index, request: r}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	done := make(chan BatchResult[T])
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				select {
+				case done <- extractBatchItem[T](ctx, e, limit, job):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	out := make(chan BatchResult[T])
+	go func() {
+		defer close(out)
+
+		progress := BatchProgress{Total: total}
+		// results finished ahead of an earlier one
+		pending := map[int]BatchResult[T]{}
+		next := 0
+
+		for r := range done {
+			progress.Done++
+			if r.Err != nil {
+				progress.Failed++
+			}
+			if r.Response != nil {
+				progress.Usage.add(&r.Response.Usage)
+				progress.Cost.add(r.Response.Cost)
+			}
+			if opts.Progress != nil {
+				opts.Progress(progress)
+			}
+
+			pending[r.Index] = r
+			for {
+				r, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				select {
+				case out <- r:
+				case <-ctx.Done():
+					return
+				}
+				next++
+			}
+		}
+	}()
+
+	return out
+}
+
+func extractBatchItem[T any](ctx context.Context, e Extractor, limit *limiter, job batchJob) BatchResult[T] {
+	result := BatchResult[T]{Index: job.index}
+
+	estimate := estimateTokens(job.request)
+	if err := limit.wait(ctx, estimate); err != nil {
+		result.Err = err
+		return result
+	}
+
+	var value T
+	resp, err := e.Extract(ctx, job.request, &value)
+
+	// a failed call may still have been billed, so it keeps the estimate
+	actual := estimate
+	if resp != nil {
+		actual = resp.Usage.TotalTokens
+	}
+	limit.settle(estimate, actual)
+
+	result.Response = resp
+	result.Err = err
+	if err == nil {
+		result.Value = &value
+	}
+	return result
+}
+
+// estimateTokens roughly estimates the tokens used by request, at 4 characters
+// a token, before it is sent. Without MaxTokens, it reserves DefaultMaxTokens
+// for the output.
+func estimateTokens(request Request) int {
+	chars := len(request.System)
+	for _, m := range request.Messages {
+		chars += len(m.Content)
+	}
+	return chars/4 + request.maxTokens()
+}
@@ -0,0 +1,290 @@
+package instructor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cohere-ai/cohere-go/v2/core"
+	"github.com/liushuangls/go-anthropic/v2"
+	openai "github.com/sashabaranov/go-openai"
+	"google.golang.org/genai"
+)
+
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+type CircuitState string
+
+const (
+	// CircuitClosed lets requests through.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen fails requests fast, until the cooldown has passed.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single probe request through, closing the circuit
+	// when it succeeds and opening it again when it fails.
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// CircuitBreaker stops calling a provider model that keeps failing. A circuit
+// per provider and model opens after threshold consecutive transport errors,
+// timeouts or 5xx errors, failing attempts fast with a *CircuitOpenError, instead of spending
+// the retry budget of every extraction on an outage. Once the cooldown has
+// passed, a probe attempt is let through to test whether the model recovered.
+//
+// Share a CircuitBreaker between clients with WithCircuitBreaker:
+//
+//	breaker := instructor.NewCircuitBreaker(5, 30*time.Second)
+//	client := instructor.FromOpenAI(openai.NewClient(key), instructor.WithCircuitBreaker(breaker))
+//
+// It is safe for concurrent use.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	circuits map[circuitKey]*circuit
+}
+
+type circuitKey struct {
+	provider Provider
+	model    string
+}
+
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	// probing is set while the probe of a half-open circuit is in flight
+	probing bool
+}
+
+// CircuitStatus is the status of the circuit of a provider model, e.g. for
+// health endpoints.
+type CircuitStatus struct {
+	Provider Provider
+	Model    string
+	State    CircuitState
+	// Failures is the number of consecutive failures.
+	Failures int
+	// RetryAt is when an open circuit lets a probe through.
+	RetryAt time.Time
+}
+
+// CircuitOpenError is returned for attempts failed fast by an open circuit.
+type CircuitOpenError struct {
+	Provider Provider
+	Model    string
+	RetryAt  time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit open for %s %s until %s", e.Provider, e.Model, e.RetryAt.Format(time.RFC3339))
+}
+
+// NewCircuitBreaker returns a CircuitBreaker opening circuits after threshold
+// consecutive failures, DefaultBreakerThreshold when zero, for cooldown,
+// DefaultBreakerCooldown when zero.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = DefaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		circuits:  map[circuitKey]*circuit{},
+	}
+}
+
+// WithCircuitBreaker checks every provider call against the circuit of its
+// provider and model in b.
+func WithCircuitBreaker(b *CircuitBreaker) Options {
+	return Options{breaker: b}
+}
+
+// State returns the state of the circuit of a provider model.
+func (b *CircuitBreaker) State(provider Provider, model string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[circuitKey{provider, model}]
+	if !ok {
+		return CircuitClosed
+	}
+	return b.state(c, time.Now())
+}
+
+// Status returns the status of every circuit that has seen a call, ordered by
+// provider and model.
+func (b *CircuitBreaker) Status() []CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	status := make([]CircuitStatus, 0, len(b.circuits))
+	for key, c := range b.circuits {
+		s := CircuitStatus{
+			Provider: key.provider,
+			Model:    key.model,
+			State:    b.state(c, now),
+			Failures: c.failures,
+		}
+		if c.state == CircuitOpen {
+			s.RetryAt = c.openedAt.Add(b.cooldown)
+		}
+		status = append(status, s)
+	}
+	sort.Slice(status, func(i, j int) bool {
+		if status[i].Provider != status[j].Provider {
+			return status[i].Provider < status[j].Provider
+		}
+		return status[i].Model < status[j].Model
+	})
+	return status
+}
+
+// state returns the state of c, which turns half-open once the cooldown has passed.
+func (b *CircuitBreaker) state(c *circuit, now time.Time) CircuitState {
+	if c.state == CircuitOpen && !now.Before(c.openedAt.Add(b.cooldown)) {
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+// allow returns an error when a call to a provider model must fail fast, or a
+// function to record the outcome of the call otherwise.
+func (b *CircuitBreaker) allow(provider Provider, model string) (func(ctx context.Context, err error), error) {
+	if b == nil {
+		return func(context.Context, error) {}, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := circuitKey{provider, model}
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{state: CircuitClosed}
+		b.circuits[key] = c
+	}
+
+	now := time.Now()
+	switch b.state(c, now) {
+	case CircuitOpen:
+		return nil, &CircuitOpenError{Provider: provider, Model: model, RetryAt: c.openedAt.Add(b.cooldown)}
+	case CircuitHalfOpen:
+		if c.probing {
+			// a single probe at a time, the others keep failing fast
+			return nil, &CircuitOpenError{Provider: provider, Model: model, RetryAt: now.Add(b.cooldown)}
+		}
+		c.state = CircuitHalfOpen
+		c.probing = true
+	}
+
+	return func(ctx context.Context, err error) {
+		b.record(c, ctx, err)
+	}, nil
+}
+
+func (b *CircuitBreaker) record(c *circuit, ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	probe := c.probing
+	c.probing = false
+
+	// a caller giving up says nothing about the provider, unlike a call
+	// outlasting its deadline
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		if probe {
+			// an open circuit past its cooldown lets the next caller probe
+			c.state, c.openedAt = CircuitOpen, time.Time{}
+		}
+		return
+	}
+
+	if !isProviderFailure(err) {
+		c.state = CircuitClosed
+		c.failures = 0
+		return
+	}
+
+	c.failures++
+	if probe || c.failures >= b.threshold {
+		c.state = CircuitOpen
+		c.openedAt = time.Now()
+	}
+}
+
+// isProviderFailure reports whether err is a transport error, a timeout or a
+// 5xx response, as opposed to an error of the request itself.
+func isProviderFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	if status, ok := errorStatusCode(err); ok {
+		return status >= 500
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// errorStatusCode returns the HTTP status of a provider API error.
+func errorStatusCode(err error) (int, bool) {
+	var openaiAPIErr *openai.APIError
+	if errors.As(err, &openaiAPIErr) {
+		return openaiAPIErr.HTTPStatusCode, true
+	}
+	var openaiReqErr *openai.RequestError
+	if errors.As(err, &openaiReqErr) {
+		return openaiReqErr.HTTPStatusCode, true
+	}
+
+	var anthropicReqErr *anthropic.RequestError
+	if errors.As(err, &anthropicReqErr) {
+		return anthropicReqErr.StatusCode, true
+	}
+	var anthropicAPIErr *anthropic.APIError
+	if errors.As(err, &anthropicAPIErr) {
+		switch {
+		case anthropicAPIErr.IsApiErr():
+			return 500, true
+		case anthropicAPIErr.IsOverloadedErr():
+			return 529, true
+		}
+		return 400, true
+	}
+
+	var googleErr genai.APIError
+	if errors.As(err, &googleErr) {
+		return googleErr.Code, true
+	}
+	var googleErrPtr *genai.APIError
+	if errors.As(err, &googleErrPtr) {
+		return googleErrPtr.Code, true
+	}
+
+	var cohereErr *core.APIError
+	if errors.As(err, &cohereErr) {
+		return cohereErr.StatusCode, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,143 @@
+package instructor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Budget limits the tokens and dollars that extractions made with a context
+// may spend. It is checked before every provider call, including streams and
+// continuations of truncated outputs, so a call in flight may overshoot it,
+// but no further calls or retries are made once it is exhausted.
+// It is safe for concurrent use.
+type Budget struct {
+	mu        sync.Mutex
+	maxTokens int
+	maxCost   float64
+	tokens    int
+	cost      Cost
+}
+
+// NewBudget creates a budget of maxTokens total tokens and maxCost US dollars.
+// A zero limit is not enforced. Extractions using models without a price are
+// refused by a budget with a dollar limit.
+func NewBudget(maxTokens int, maxCost float64) *Budget {
+	return &Budget{
+		maxTokens: maxTokens,
+		maxCost:   maxCost,
+	}
+}
+
+type budgetsKey struct{}
+
+// WithBudget returns a context whose extractions are limited by budget, in
+// addition to any budgets attached to parent contexts.
+func WithBudget(ctx context.Context, budget *Budget) context.Context {
+	parents := budgetsFromContext(ctx)
+	budgets := append(parents[:len(parents):len(parents)], budget)
+	return context.WithValue(ctx, budgetsKey{}, budgets)
+}
+
+func budgetsFromContext(ctx context.Context) []*Budget {
+	budgets, _ := ctx.Value(budgetsKey{}).([]*Budget)
+	return budgets
+}
+
+// Spent returns the tokens and cost spent so far.
+func (b *Budget) Spent() (tokens int, cost Cost) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens, b.cost
+}
+
+// Exceeded reports whether the budget is exhausted.
+func (b *Budget) Exceeded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exceeded(b.tokens, b.cost)
+}
+
+func (b *Budget) exceeded(tokens int, cost Cost) bool {
+	return (b.maxTokens > 0 && tokens >= b.maxTokens) ||
+		(b.maxCost > 0 && cost.Total >= b.maxCost)
+}
+
+func (b *Budget) spend(usage Usage, cost Cost) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += usage.InputTokens + usage.OutputTokens
+	b.cost.add(cost)
+}
+
+// check returns an error when the budget is exhausted, counting usage and
+// cost of calls not yet spent against it.
+func (b *Budget) check(usage Usage, cost Cost) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	tokens := b.tokens + usage.InputTokens + usage.OutputTokens
+	spent := b.cost
+	spent.add(cost)
+	if !b.exceeded(tokens, spent) {
+		return nil
+	}
+
+	return &BudgetExceededError{
+		MaxTokens: b.maxTokens,
+		MaxCost:   b.maxCost,
+		Tokens:    tokens,
+		Cost:      spent,
+	}
+}
+
+// BudgetExceededError is returned when an extraction is refused or stopped
+// retrying because a Budget attached to its context is exhausted.
+type BudgetExceededError struct {
+	MaxTokens int
+	MaxCost   float64
+
+	// Tokens and Cost are the amounts spent against the budget so far.
+	Tokens int
+	Cost   Cost
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("budget exceeded: spent %d tokens (limit %d) and $%.6f (limit $%.6f)",
+		e.Tokens, e.MaxTokens, e.Cost.Total, e.MaxCost)
+}
+
+// UnpricedModelError is returned when an extraction limited by a dollar Budget
+// would call a model missing from the price tables, whose cost could not be
+// counted against the budget. Price the model with WithPrices.
+type UnpricedModelError struct {
+	Provider Provider
+	Model    string
+}
+
+func (e *UnpricedModelError) Error() string {
+	return fmt.Sprintf("budget: cannot enforce a dollar limit on unpriced %s model %q", e.Provider, e.Model)
+}
+
+// checkBudgets returns an error when a budget of ctx is exhausted, counting
+// the usage of calls to model not yet spent against it, or limits dollars
+// while model has no price.
+func (o Options) checkBudgets(ctx context.Context, provider Provider, model string, usage Usage) error {
+	cost := o.cost(provider, model, usage)
+	for _, budget := range budgetsFromContext(ctx) {
+		if budget.maxCost > 0 && cost.Unpriced {
+			return &UnpricedModelError{Provider: provider, Model: model}
+		}
+		if err := budget.check(usage, cost); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func spendBudgets(ctx context.Context, usage Usage, cost Cost) {
+	for _, budget := range budgetsFromContext(ctx) {
+		budget.spend(usage, cost)
+	}
+}
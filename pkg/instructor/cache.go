@@ -0,0 +1,272 @@
+package instructor
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Cache stores the results of successful extractions. Keys are hex encoded
+// SHA-256 hashes of the provider request, response schema and the options
+// changing the result, such as the mode.
+//
+// Errors returned by a Cache never fail an extraction: a failed Get is treated
+// as a miss and a failed Set is ignored.
+type Cache interface {
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte) error
+}
+
+// WithCache serves repeated extractions from cache without calling the
+// provider. Only successful, validated extractions are stored and streaming
+// extractions are never cached.
+//
+// A cache hit returns the native response exactly as it was first returned,
+// including its usage. The Metadata of a cache hit is the one of the cached
+// extraction, with CacheHit set and zero attempts, usage and cost.
+func WithCache(cache Cache) Options {
+	return Options{cache: cache}
+}
+
+// cacheEntry is what is stored in a Cache for an extraction.
+type cacheEntry struct {
+	// Value is the extracted response object.
+	Value json.RawMessage `json:"value"`
+	// Response is the native provider response.
+	Response json.RawMessage `json:"response"`
+	// Metadata is the metadata of the extraction.
+	Metadata Metadata `json:"metadata"`
+}
+
+// cacheKey hashes everything that determines the outcome of an extraction.
+func cacheKey(i Instructor, request interface{}, schema *Schema) (string, error) {
+	options := i.options()
+	b, err := json.Marshal(struct {
+		Provider      Provider    `json:"provider"`
+		Mode          Mode        `json:"mode"`
+		Validate      bool        `json:"validate"`
+		Confidence    *bool       `json:"confidence,omitempty"`
+		Reasoning     *bool       `json:"reasoning,omitempty"`
+		Truncation    *Truncation `json:"truncation,omitempty"`
+		PromptCaching *bool       `json:"prompt_caching,omitempty"`
+		Schema        string      `json:"schema"`
+		Request       interface{} `json:"request"`
+	}{
+		Provider:      i.Provider(),
+		Mode:          i.Mode(),
+		Validate:      i.Validate(),
+		Confidence:    options.confidence,
+		Reasoning:     options.reasoning,
+		Truncation:    options.truncation,
+		PromptCaching: options.promptCaching,
+		Schema:        schema.String,
+		Request:       request,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// cacheGet looks up key and decodes a hit into response, returning the
+// rehydrated native response and the metadata of the cached extraction.
+func cacheGet(ctx context.Context, i Instructor, cache Cache, key string, response any) (interface{}, Metadata, bool) {
+	b, ok, err := cache.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, Metadata{}, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return nil, Metadata{}, false
+	}
+
+	resp := i.emptyResponseWithUsageSum(&Usage{})
+	if err := json.Unmarshal(entry.Response, resp); err != nil {
+		return nil, Metadata{}, false
+	}
+	if err := json.Unmarshal(entry.Value, &response); err != nil {
+		return nil, Metadata{}, false
+	}
+
+	return resp, entry.Metadata, true
+}
+
+func cacheSet(ctx context.Context, cache Cache, key string, response any, resp interface{}, md Metadata) {
+	value, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	native, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	b, err := json.Marshal(cacheEntry{Value: value, Response: native, Metadata: md})
+	if err != nil {
+		return
+	}
+
+	_ = cache.Set(ctx, key, b)
+}
+
+// MemoryCache is an in-memory Cache that evicts the least recently used
+// entries. It is safe for concurrent use.
+type MemoryCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type memoryCacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+var _ Cache = &MemoryCache{}
+
+// NewMemoryCache creates a cache holding up to size entries for ttl each.
+// A zero size or ttl is unlimited.
+func NewMemoryCache(size int, ttl time.Duration) *MemoryCache {
+	return &MemoryCache{
+		size:    size,
+		ttl:     ttl,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := elem.Value.(*memoryCacheEntry)
+	if !entry.expires.IsZero() && !time.Now().Before(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = time.Now().Add(c.ttl)
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*memoryCacheEntry)
+		entry.value, entry.expires = value, expires
+		c.order.MoveToFront(elem)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&memoryCacheEntry{key: key, value: value, expires: expires})
+
+	if c.size > 0 && c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+
+	return nil
+}
+
+// Len returns the number of entries in the cache, including expired ones not
+// yet evicted.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// DiskCache is a Cache storing one file per entry in a directory. Entries
+// expire ttl after they were written. It is safe for concurrent use, including
+// by multiple processes sharing the directory.
+type DiskCache struct {
+	dir string
+	ttl time.Duration
+}
+
+var _ Cache = &DiskCache{}
+
+// NewDiskCache creates a cache in dir, creating the directory if needed.
+// A zero ttl never expires entries.
+func NewDiskCache(dir string, ttl time.Duration) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir, ttl: ttl}, nil
+}
+
+func (c *DiskCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+func (c *DiskCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	path := c.path(key)
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if c.ttl > 0 && time.Since(info.ModTime()) >= c.ttl {
+		_ = os.Remove(path)
+		return nil, false, nil
+	}
+
+	value, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (c *DiskCache) Set(ctx context.Context, key string, value []byte) error {
+	// Write to a temporary file first so readers never see a partial entry.
+	f, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(value); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), c.path(key))
+}
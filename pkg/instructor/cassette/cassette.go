@@ -0,0 +1,354 @@
+// Package cassette records the HTTP interactions of provider clients to a file
+// and replays them offline, so code using Instructor can be tested without
+// network access or API keys.
+//
+//	rec, err := cassette.New("testdata/person.json")
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer rec.Stop()
+//
+//	client := instructor.FromOpenAI(rec.OpenAI(os.Getenv("OPENAI_API_KEY")))
+//
+// The first run records the interactions against the live API; later runs
+// replay them. Streaming (server-sent event) responses are recorded in full and
+// replayed as a single stream.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Mode selects whether a Recorder records or replays interactions.
+type Mode int
+
+const (
+	// ModeAuto replays the cassette if its file exists and records it otherwise.
+	ModeAuto Mode = iota
+	// ModeReplay only replays. Requests without a recorded interaction fail.
+	ModeReplay
+	// ModeRecord sends every request and overwrites the cassette on Stop.
+	ModeRecord
+)
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Matcher reports whether a recorded request matches an outgoing one.
+// The body of req has already been read into body.
+type Matcher func(req *http.Request, body []byte, recorded Request) bool
+
+// DefaultRedactedHeaders are never written to cassettes.
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Api-Key",
+	"X-Api-Key",
+	"X-Goog-Api-Key",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// DefaultRedactedParams are removed from recorded URLs.
+var DefaultRedactedParams = []string{"key", "api_key"}
+
+type Option func(*Recorder)
+
+// WithMode sets the mode of the recorder. Defaults to ModeAuto.
+func WithMode(mode Mode) Option {
+	return func(r *Recorder) {
+		r.mode = mode
+	}
+}
+
+// WithTransport sets the transport used to record interactions. Defaults to
+// http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// WithMatcher replaces DefaultMatcher.
+func WithMatcher(matcher Matcher) Option {
+	return func(r *Recorder) {
+		r.matcher = matcher
+	}
+}
+
+// WithRedactedHeaders adds headers that are not written to the cassette.
+func WithRedactedHeaders(headers ...string) Option {
+	return func(r *Recorder) {
+		r.redactedHeaders = append(r.redactedHeaders, headers...)
+	}
+}
+
+// Recorder is an http.RoundTripper that records or replays interactions.
+// It is safe for concurrent use.
+type Recorder struct {
+	path            string
+	mode            Mode
+	transport       http.RoundTripper
+	matcher         Matcher
+	redactedHeaders []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+var _ http.RoundTripper = &Recorder{}
+
+// New creates a recorder for the cassette at path.
+func New(path string, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:            path,
+		mode:            ModeAuto,
+		transport:       http.DefaultTransport,
+		matcher:         DefaultMatcher,
+		redactedHeaders: append([]string(nil), DefaultRedactedHeaders...),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.mode == ModeRecord {
+		return r, nil
+	}
+
+	b, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && r.mode == ModeAuto:
+		r.mode = ModeRecord
+		return r, nil
+	case err != nil:
+		return nil, fmt.Errorf("cassette: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &r.cassette); err != nil {
+		return nil, fmt.Errorf("cassette: decoding %s: %w", path, err)
+	}
+	r.mode = ModeReplay
+	r.used = make([]bool, len(r.cassette.Interactions))
+
+	return r, nil
+}
+
+// Recording reports whether the recorder sends requests rather than replaying them.
+func (r *Recorder) Recording() bool {
+	return r.mode == ModeRecord
+}
+
+// HTTPClient returns an HTTP client using the recorder as its transport.
+func (r *Recorder) HTTPClient() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Stop writes the recorded interactions to the cassette file. It does nothing
+// when replaying.
+func (r *Recorder) Stop() error {
+	if !r.Recording() {
+		return nil
+	}
+
+	r.mu.Lock()
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	if err := os.WriteFile(r.path, b, 0o644); err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	return nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Recording() {
+		return r.record(req, body)
+	}
+	return r.replay(req, body)
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for idx, interaction := range r.cassette.Interactions {
+		if r.used[idx] || !r.matcher(req, body, interaction.Request) {
+			continue
+		}
+		r.used[idx] = true
+
+		resp := interaction.Response
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)),
+			StatusCode:    resp.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        resp.Headers.Clone(),
+			Body:          io.NopCloser(strings.NewReader(resp.Body)),
+			ContentLength: int64(len(resp.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("cassette: no recorded interaction for %s %s in %s", req.Method, redactURL(req.URL), r.path)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+
+	resp, err := r.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := &Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     redactURL(req.URL),
+			Headers: r.redact(req.Header),
+			Body:    string(body),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    r.redact(resp.Header),
+		},
+	}
+
+	// The interaction is saved once the body has been consumed, so streams
+	// reach the caller as they arrive.
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		done: func(b []byte) {
+			interaction.Response.Body = string(b)
+
+			r.mu.Lock()
+			r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+			r.mu.Unlock()
+		},
+	}
+
+	return resp, nil
+}
+
+func (r *Recorder) redact(headers http.Header) http.Header {
+	headers = headers.Clone()
+	for _, h := range r.redactedHeaders {
+		headers.Del(h)
+	}
+	return headers
+}
+
+func redactURL(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	for _, param := range DefaultRedactedParams {
+		query.Del(param)
+	}
+	redacted.RawQuery = query.Encode()
+	redacted.User = nil
+	return redacted.String()
+}
+
+// DefaultMatcher matches requests by method, URL and body. JSON bodies are
+// compared semantically, ignoring formatting and key order.
+func DefaultMatcher(req *http.Request, body []byte, recorded Request) bool {
+	if req.Method != recorded.Method || redactURL(req.URL) != recorded.URL {
+		return false
+	}
+	return equalBodies(body, []byte(recorded.Body))
+}
+
+func equalBodies(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+
+	ca, _ := json.Marshal(va)
+	cb, _ := json.Marshal(vb)
+	return bytes.Equal(ca, cb)
+}
+
+type recordingBody struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	once sync.Once
+	done func([]byte)
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	// Record whatever the caller did not read, so replays see the full body.
+	_, _ = io.Copy(&b.buf, b.ReadCloser)
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *recordingBody) finish() {
+	b.once.Do(func() {
+		b.done(b.buf.Bytes())
+	})
+}
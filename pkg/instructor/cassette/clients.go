@@ -0,0 +1,64 @@
+package cassette
+
+import (
+	"context"
+
+	cohereclient "github.com/cohere-ai/cohere-go/v2/client"
+	"github.com/liushuangls/go-anthropic/v2"
+	openai "github.com/sashabaranov/go-openai"
+	"google.golang.org/genai"
+)
+
+// replayKey stands in for an empty API key when replaying, since some clients
+// refuse to be built without one.
+const replayKey = "replay"
+
+func (r *Recorder) apiKey(key string) string {
+	if key == "" && !r.Recording() {
+		return replayKey
+	}
+	return key
+}
+
+// OpenAI returns an OpenAI client using the recorder.
+func (r *Recorder) OpenAI(apiKey string) *openai.Client {
+	return r.OpenAIWithConfig(openai.DefaultConfig(r.apiKey(apiKey)))
+}
+
+// OpenAIWithConfig returns an OpenAI client for config using the recorder, for
+// example to record an OpenAI-compatible server.
+func (r *Recorder) OpenAIWithConfig(config openai.ClientConfig) *openai.Client {
+	config.HTTPClient = r.HTTPClient()
+	return openai.NewClientWithConfig(config)
+}
+
+// Anthropic returns an Anthropic client using the recorder.
+func (r *Recorder) Anthropic(apiKey string, opts ...anthropic.ClientOption) *anthropic.Client {
+	opts = append(opts, anthropic.WithHTTPClient(r.HTTPClient()))
+	return anthropic.NewClient(r.apiKey(apiKey), opts...)
+}
+
+// Google returns a Gemini API client using the recorder.
+func (r *Recorder) Google(ctx context.Context, apiKey string) (*genai.Client, error) {
+	return r.GoogleWithConfig(ctx, &genai.ClientConfig{
+		APIKey:  r.apiKey(apiKey),
+		Backend: genai.BackendGeminiAPI,
+	})
+}
+
+// GoogleWithConfig returns a Google client for config using the recorder.
+func (r *Recorder) GoogleWithConfig(ctx context.Context, config *genai.ClientConfig) (*genai.Client, error) {
+	cfg := *config
+	cfg.HTTPClient = r.HTTPClient()
+	return genai.NewClient(ctx, &cfg)
+}
+
+// Cohere returns a Cohere client using the recorder. Retries are left to
+// Instructor, so that replayed interactions line up with recorded ones.
+func (r *Recorder) Cohere(apiKey string) *cohereclient.Client {
+	return cohereclient.NewClient(
+		cohereclient.WithToken(r.apiKey(apiKey)),
+		cohereclient.WithHTTPClient(r.HTTPClient()),
+		cohereclient.WithMaxAttempts(1),
+	)
+}
@@ -5,17 +5,56 @@ import (
 	"encoding/json"
 	"errors"
 	"reflect"
-
-	"github.com/go-playground/validator/v10"
 )
 
-type UsageSum struct {
+// Usage is the provider-neutral token usage of one or more provider calls.
+//
+// Whatever the provider's own convention, InputTokens includes
+// CachedInputTokens and CacheCreationTokens, and OutputTokens includes
+// ReasoningTokens.
+type Usage struct {
 	InputTokens  int
 	OutputTokens int
 	TotalTokens  int
+
+	CachedInputTokens   int
+	CacheCreationTokens int
+	ReasoningTokens     int
+
+	// Attempts breaks the usage of an extraction down per provider call.
+	Attempts []Usage
+}
+
+// Deprecated: use Usage.
+type UsageSum = Usage
+
+func (u *Usage) add(other *Usage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.TotalTokens += other.TotalTokens
+	u.CachedInputTokens += other.CachedInputTokens
+	u.CacheCreationTokens += other.CacheCreationTokens
+	u.ReasoningTokens += other.ReasoningTokens
+}
+
+func (u *Usage) addAttempt(attempt Usage) {
+	u.add(&attempt)
+	u.Attempts = append(u.Attempts, attempt)
 }
 
 func chatHandler(i Instructor, ctx context.Context, request interface{}, response any) (interface{}, error) {
+	return handleChat(i, ctx, request, nil, response)
+}
+
+// handleChat runs an extraction of request, the native form of neutral when
+// the extraction was started by Extract.
+func handleChat(i Instructor, ctx context.Context, request interface{}, neutral *Request, response any) (interface{}, error) {
+	if hedge := i.options().hedge; hedge != nil && ctx.Value(hedgedKey{}) == nil {
+		return hedgedChat(i, ctx, request, neutral, response, hedge)
+	}
+	if consistency := i.options().consistency; consistency != nil && ctx.Value(sampledKey{}) == nil {
+		return consistentChat(i, ctx, request, neutral, response, consistency)
+	}
 
 	var err error
 
@@ -26,18 +65,182 @@ func chatHandler(i Instructor, ctx context.Context, request interface{}, respons
 		return nil, err
 	}
 
+	options := i.options()
+	hooks := options.allHooks()
+	info := newExtractionInfo(i, request, t, schema)
+	// the extractions of a hedge only report their attempts, see hedgedChat
+	hedged := ctx.Value(hedgedKey{}) != nil
+	if !hedged {
+		ctx = hooks.extractionStart(ctx, info)
+	}
+
 	// keep a running total of usage
-	usage := &UsageSum{}
+	usage := &Usage{}
+	// usage and cost of every attempt, including the one returned to the caller
+	total := &Usage{}
+	cost := Cost{}
+
+	// the client and request of the next attempt, changed by escalation steps
+	client, req := i, request
+	escalation := newLadder(options.escalation, neutral)
+	failures := 0
+
+	attempts := 0
+	cacheHit := false
+	coalesced := false
+	var confidence map[string]float64
+	var reasoning string
+	metadata := func() Metadata {
+		return Metadata{
+			Provider:   info.Provider,
+			Model:      info.Model,
+			Attempts:   attempts,
+			Usage:      *total,
+			Cost:       cost,
+			CacheHit:   cacheHit,
+			Coalesced:  coalesced,
+			Confidence: confidence,
+			Reasoning:  reasoning,
+		}
+	}
+	end := func(resp interface{}, err error) (interface{}, error) {
+		if md := metadataFromContext(ctx); md != nil {
+			*md = metadata()
+		}
+
+		// hooks are given the cause, with the usage and cost in the result
+		if !hedged {
+			hooks.extractionEnd(ctx, info, ExtractionResult{Attempts: attempts, Usage: *total, Cost: cost, CacheHit: cacheHit, Coalesced: coalesced, Err: err})
+		}
+
+		if err != nil {
+			err = &ExtractionError{Err: err, Attempts: attempts, Usage: *total, Cost: cost}
+		}
+		return resp, err
+	}
+
+	// escalate takes the escalation steps due after a failed attempt
+	escalate := func(output string, err error) error {
+		next, nextReq, ok, err := escalation.escalate(client, req, failures, output, err)
+		if err != nil || !ok {
+			return err
+		}
+		client, req = next, nextReq
+		info.Provider = client.Provider()
+		info.Model = client.requestModel(req)
+		info.Mode = client.Mode()
+		info.client, info.request = client, req
+		return nil
+	}
+
+	// a native request cannot switch providers halfway through
+	if err := escalation.check(i); err != nil {
+		return end(i.emptyResponseWithUsageSum(usage), err)
+	}
+
+	var key string
+	// samples of a self-consistent extraction and hedged extractions must be
+	// made independently
+	if (options.cache != nil || options.flights != nil) && ctx.Value(sampledKey{}) == nil && ctx.Value(hedgedExtractionKey{}) == nil {
+		// a request that cannot be hashed is neither cached nor coalesced
+		key, _ = cacheKey(i, request, schema)
+	}
+
+	if options.cache != nil && key != "" {
+		if resp, md, ok := cacheGet(ctx, i, options.cache, key, response); ok {
+			cacheHit = true
+			// the model may have been escalated to
+			if md.Model != "" {
+				info.Model = md.Model
+			}
+			confidence, reasoning = md.Confidence, md.Reasoning
+			return end(resp, nil)
+		}
+	}
+
+	for options.flights != nil && key != "" {
+		f, leader := options.flights.join(key)
+		if leader {
+			// share the result of this extraction with the ones waiting for it
+			finish := end
+			end = func(resp interface{}, err error) (interface{}, error) {
+				options.flights.land(ctx, key, f, response, resp, err)
+				return finish(resp, err)
+			}
+			break
+		}
+
+		resp, ok, err := f.wait(ctx, i, response)
+		if ok {
+			// unless the caller gave up waiting
+			coalesced = ctx.Err() == nil
+			return end(resp, err)
+		}
+	}
 
 	for attempt := 0; attempt <= i.MaxRetries(); attempt++ {
+		// a canceled extraction, such as the loser of a hedge, starts no attempt
+		if err := ctx.Err(); err != nil {
+			return end(client.emptyResponseWithUsageSum(usage), err)
+		}
+
+		if err := options.checkBudgets(ctx, info.Provider, info.Model, Usage{}); err != nil {
+			return end(client.emptyResponseWithUsageSum(usage), err)
+		}
+
+		record, err := options.breaker.allow(info.Provider, info.Model)
+		if err != nil {
+			return end(client.emptyResponseWithUsageSum(usage), err)
+		}
+
+		attempts++
+
+		attemptCtx := hooks.attemptStart(ctx, info, attempt)
+
+		text, resp, err := client.chat(attemptCtx, req, schema)
+		if err == nil && options.truncation != nil {
+			text, resp, err = options.truncation.continueOutput(attemptCtx, client, req, schema, text, resp, func(spent Usage) error {
+				return options.checkBudgets(ctx, info.Provider, info.Model, spent)
+			})
+		}
+		record(attemptCtx, err)
+
+		result := AttemptResult{
+			Attempt:      attempt,
+			Usage:        *client.countUsageFromResponse(resp, &Usage{}),
+			FinishReason: client.finishReason(resp),
+			Output:       text,
+		}
+		result.Cost = options.cost(info.Provider, info.Model, result.Usage)
+		total.addAttempt(result.Usage)
+		cost.add(result.Cost)
+		recordCost(ctx, info.Provider, info.Model, result.Usage, result.Cost)
+		spendBudgets(ctx, result.Usage, result.Cost)
+
+		// a truncated output fails to decode, and would truncate again as is
+		if options.truncation != nil && truncated(result.FinishReason) {
+			result.Err = &TruncatedOutputError{Output: text, FinishReason: result.FinishReason}
+			hooks.attemptEnd(attemptCtx, info, result)
+
+			next, ok := options.truncation.raiseLimit(client, req, result.Usage.OutputTokens)
+			if !ok {
+				return end(client.emptyResponseWithResponseUsage(resp), result.Err)
+			}
+			usage.add(&result.Usage)
+			req = next
+			continue
+		}
 
-		text, resp, err := i.chat(ctx, request, schema)
 		if err != nil {
+			result.Err = err
+			hooks.attemptEnd(attemptCtx, info, result)
+
 			// no retry on non-marshalling/validation errors
-			return i.emptyResponseWithResponseUsage(resp), err
+			return end(client.emptyResponseWithResponseUsage(resp), err)
 		}
 
 		text = extractJSON(&text)
+		result.JSON = text
 
 		err = json.Unmarshal([]byte(text), &response)
 		if err != nil {
@@ -47,12 +250,18 @@ func chatHandler(i Instructor, ctx context.Context, request interface{}, respons
 			// Currently, its just recalling with no new information
 			// or attempt to fix the error with the last generated JSON
 
-			i.countUsageFromResponse(resp, usage)
+			result.DecodeErr = err
+			hooks.attemptEnd(attemptCtx, info, result)
+
+			usage.add(&result.Usage)
+			failures++
+			if err := escalate(result.Output, err); err != nil {
+				return end(client.emptyResponseWithUsageSum(usage), err)
+			}
 			continue
 		}
 
-		if i.Validate() {
-			validate = validator.New()
+		if client.Validate() {
 			// Validate the response structure against the defined model using the validator
 			err = validate.Struct(response)
 
@@ -60,13 +269,33 @@ func chatHandler(i Instructor, ctx context.Context, request interface{}, respons
 				// TODO:
 				// add more sophisticated retry logic (send back validator error and parse error for model to fix).
 
-				i.countUsageFromResponse(resp, usage)
+				result.ValidationErr = err
+				hooks.attemptEnd(attemptCtx, info, result)
+
+				usage.add(&result.Usage)
+				failures++
+				if err := escalate(result.Output, err); err != nil {
+					return end(client.emptyResponseWithUsageSum(usage), err)
+				}
 				continue
 			}
 		}
 
-		return i.addUsageSumToResponse(resp, usage)
+		hooks.attemptEnd(attemptCtx, info, result)
+
+		if s, ok := client.(scorer); ok && options.confidence != nil && *options.confidence {
+			confidence = s.confidence(resp, schema)
+		}
+		if r, ok := client.(reasoner); ok && options.reasoning != nil && *options.reasoning {
+			reasoning = r.reasoning(resp)
+		}
+
+		resp, err = client.addUsageSumToResponse(resp, usage)
+		if err == nil && options.cache != nil && key != "" {
+			cacheSet(ctx, options.cache, key, response, resp, metadata())
+		}
+		return end(resp, err)
 	}
 
-	return i.emptyResponseWithUsageSum(usage), errors.New("hit max retry attempts")
+	return end(client.emptyResponseWithUsageSum(usage), errors.New("hit max retry attempts"))
 }
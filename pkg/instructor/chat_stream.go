@@ -4,17 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"reflect"
+	"regexp"
 	"strings"
-
-	"github.com/go-playground/validator/v10"
+	"sync"
 )
 
 type StreamWrapper[T any] struct {
 	Items []T `json:"items"`
 }
 
-const WRAPPER_END = `"items": [`
-
 func chatStreamHandler(i Instructor, ctx context.Context, request interface{}, response any) (<-chan interface{}, error) {
 
 	responseType := reflect.TypeOf(response)
@@ -33,27 +31,86 @@ func chatStreamHandler(i Instructor, ctx context.Context, request interface{}, r
 		return nil, err
 	}
 
-	ch, err := i.chatStream(ctx, request, schema)
+	options := i.options()
+	hooks := options.allHooks()
+	info := newExtractionInfo(i, request, responseType, schema)
+	info.Schema = typeName(responseType)
+	info.Stream = true
+	ctx = hooks.extractionStart(ctx, info)
+	attemptCtx := hooks.attemptStart(ctx, info, 0)
+
+	end := func(usage Usage, cost Cost, err error) {
+		var total Usage
+		total.addAttempt(usage)
+		hooks.attemptEnd(attemptCtx, info, AttemptResult{Usage: usage, Cost: cost, Err: err})
+		hooks.extractionEnd(ctx, info, ExtractionResult{Attempts: 1, Usage: total, Cost: cost, Err: err})
+	}
+
+	if err := options.checkBudgets(ctx, info.Provider, info.Model, Usage{}); err != nil {
+		end(Usage{}, Cost{}, err)
+		return nil, err
+	}
+
+	record, err := options.breaker.allow(info.Provider, info.Model)
 	if err != nil {
+		end(Usage{}, Cost{}, err)
 		return nil, err
 	}
 
-	shouldValidate := i.Validate()
-	if shouldValidate {
-		validate = validator.New()
+	usage := &streamUsage{}
+	ch, err := i.chatStream(context.WithValue(attemptCtx, streamUsageKey{}, usage), request, schema)
+	record(attemptCtx, err)
+	if err != nil {
+		end(Usage{}, Cost{}, err)
+		return nil, err
 	}
 
-	parsedChan := parseStream(ctx, ch, shouldValidate, responseType)
+	shouldValidate := i.Validate()
+
+	parsedChan := parseStream(attemptCtx, ch, shouldValidate, responseType, func() {
+		spent := usage.get()
+		cost := options.cost(info.Provider, info.Model, spent)
+		recordCost(ctx, info.Provider, info.Model, spent, cost)
+		spendBudgets(ctx, spent, cost)
+		end(spent, cost, attemptCtx.Err())
+	})
 
 	return parsedChan, nil
 }
 
-func parseStream(ctx context.Context, ch <-chan string, shouldValidate bool, responseType reflect.Type) <-chan interface{} {
+type streamUsageKey struct{}
+
+// streamUsage is the usage reported by a provider stream, which only arrives
+// with its last events.
+type streamUsage struct {
+	mu    sync.Mutex
+	usage Usage
+}
+
+func (u *streamUsage) get() Usage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.usage
+}
+
+// reportStreamUsage sets the usage of the stream made with ctx, replacing any
+// usage reported before, as providers report running totals.
+func reportStreamUsage(ctx context.Context, usage *Usage) {
+	if u, ok := ctx.Value(streamUsageKey{}).(*streamUsage); ok {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		u.usage = *usage
+	}
+}
+
+func parseStream(ctx context.Context, ch <-chan string, shouldValidate bool, responseType reflect.Type, done func()) <-chan interface{} {
 
 	parsedChan := make(chan any)
 
 	go func() {
+		// the extraction is over, spent and reported, once the channel closes
 		defer close(parsedChan)
+		defer done()
 
 		buffer := new(strings.Builder)
 		inArray := false
@@ -84,62 +141,65 @@ func parseStream(ctx context.Context, ch <-chan string, shouldValidate bool, res
 	return parsedChan
 }
 
+func typeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+var wrapperStart = regexp.MustCompile(`"items"\s*:\s*\[`)
+
 func startArray(buffer *strings.Builder) bool {
 
 	data := buffer.String()
 
-	idx := strings.Index(data, WRAPPER_END)
-	if idx == -1 {
+	loc := wrapperStart.FindStringIndex(data)
+	if loc == nil {
 		return false
 	}
 
-	trimmed := strings.TrimSpace(data[idx+len(WRAPPER_END):])
+	trimmed := strings.TrimSpace(data[loc[1]:])
 	buffer.Reset()
 	buffer.WriteString(trimmed)
 
 	return true
 }
 
+// processBuffer sends every complete element in buffer, leaving the start of
+// the next incomplete one. Elements that fail to decode or validate are dropped.
 func processBuffer(buffer *strings.Builder, parsedChan chan<- interface{}, shouldValidate bool, responseType reflect.Type) {
 
-	data := buffer.String()
+	for {
+		// Skip the separators between elements
+		data := strings.TrimLeft(buffer.String(), " \t\r\n,")
 
-	data, remaining := getFirstFullJSONElement(&data)
+		element, remaining := getFirstFullJSONElement(&data)
 
-	decoder := json.NewDecoder(strings.NewReader(data))
+		buffer.Reset()
+		if element == "" {
+			buffer.WriteString(data)
+			return
+		}
+		buffer.WriteString(remaining)
 
-	for decoder.More() {
 		instance := reflect.New(responseType).Interface()
-		err := decoder.Decode(instance)
-		if err != nil {
-			break
+		if err := json.Unmarshal([]byte(element), instance); err != nil {
+			continue
 		}
 
 		if shouldValidate {
 			// Validate the instance
-			err = validate.Struct(instance)
-			if err != nil {
-				break
+			if err := validate.Struct(instance); err != nil {
+				continue
 			}
 		}
 
 		parsedChan <- instance
-
-		buffer.Reset()
-		buffer.WriteString(remaining)
 	}
 }
 
 func processRemainingBuffer(buffer *strings.Builder, parsedChan chan<- interface{}, shouldValidate bool, responseType reflect.Type) {
-
-	data := buffer.String()
-
-	data = extractJSON(&data)
-
-	if idx := strings.LastIndex(data, "]"); idx != -1 {
-		data = data[:idx]
-	}
-
+	// The closing brackets of the wrapper are never part of an element.
 	processBuffer(buffer, parsedChan, shouldValidate, responseType)
-
 }
@@ -0,0 +1,104 @@
+package instructor
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// WithCoalescing deduplicates identical extractions running at the same time.
+// Extractions are identical when they hash to the same key as WithCache uses:
+// the same provider, mode, request and response schema. Only the first one
+// calls the provider, and the others wait for its result.
+//
+// Every waiter decodes its own copy of the validated result and of the native
+// response, whose usage is the one of the call that was made. The Metadata of
+// a waiter has Coalesced set and zero attempts, usage and cost. Clients given
+// the same WithCoalescing option share in-flight calls:
+//
+//	coalesce := instructor.WithCoalescing()
+//	a := instructor.FromOpenAI(client, coalesce)
+//	b := instructor.FromOpenAI(client, coalesce, instructor.WithValidation())
+func WithCoalescing() Options {
+	return Options{flights: &flightGroup{calls: map[string]*flight{}}}
+}
+
+// flightGroup tracks the extractions in flight by key.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+// flight is an extraction in flight, whose result is shared once done is closed.
+type flight struct {
+	done chan struct{}
+
+	value    []byte
+	response []byte
+	err      error
+	// retry is set when the result must not be shared, e.g. when the caller
+	// making the call gave up
+	retry bool
+}
+
+// join returns the flight of key, and whether the caller leads it by making
+// the call.
+func (g *flightGroup) join(key string) (*flight, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if f, ok := g.calls[key]; ok {
+		return f, false
+	}
+
+	f := &flight{done: make(chan struct{})}
+	g.calls[key] = f
+	return f, true
+}
+
+// land shares the result of the flight of key with its waiters.
+func (g *flightGroup) land(ctx context.Context, key string, f *flight, response any, resp interface{}, err error) {
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		f.retry = true
+	case err != nil:
+		f.err = err
+	default:
+		var errValue, errResponse error
+		f.value, errValue = json.Marshal(response)
+		f.response, errResponse = json.Marshal(resp)
+		f.retry = errValue != nil || errResponse != nil
+	}
+
+	close(f.done)
+}
+
+// wait waits for the result of f and decodes it into response, returning the
+// native response. It returns false when the caller must make the call itself.
+func (f *flight) wait(ctx context.Context, i Instructor, response any) (interface{}, bool, error) {
+	select {
+	case <-ctx.Done():
+		return nil, true, ctx.Err()
+	case <-f.done:
+	}
+
+	if f.retry {
+		return nil, false, nil
+	}
+	if f.err != nil {
+		return i.emptyResponseWithUsageSum(&Usage{}), true, f.err
+	}
+
+	resp := i.emptyResponseWithUsageSum(&Usage{})
+	if err := json.Unmarshal(f.response, resp); err != nil {
+		return nil, false, nil
+	}
+	if err := json.Unmarshal(f.value, &response); err != nil {
+		return nil, false, nil
+	}
+	return resp, true, nil
+}
@@ -26,6 +26,55 @@ func (i *InstructorCohere) Chat(
 	return resp.(*cohere.NonStreamedChatResponse), nil
 }
 
+func (i *InstructorCohere) Extract(ctx context.Context, request Request, responseType any) (*Response, error) {
+	return extract(i, ctx, request, responseType)
+}
+
+func (i *InstructorCohere) nativeRequest(request Request) (interface{}, error) {
+	req := &cohere.ChatRequest{}
+	if request.Model != "" {
+		req.Model = toPtr(request.Model)
+	}
+	if system := request.system(); system != "" {
+		req.Preamble = toPtr(system)
+	}
+	if request.Temperature != nil {
+		req.Temperature = toPtr(float64(*request.Temperature))
+	}
+	if request.MaxTokens > 0 {
+		req.MaxTokens = toPtr(request.MaxTokens)
+	}
+
+	// the last user message is the message, the ones before it the history
+	messages := make([]Message, 0, len(request.Messages))
+	for _, m := range request.Messages {
+		if m.Role == RoleSystem {
+			continue
+		}
+		if len(m.Images) > 0 {
+			return nil, fmt.Errorf("images are not supported for %s", i.Provider())
+		}
+		messages = append(messages, m)
+	}
+	if len(messages) == 0 || messages[len(messages)-1].Role != RoleUser {
+		return nil, fmt.Errorf("the last message must be a user message for %s", i.Provider())
+	}
+
+	for _, m := range messages[:len(messages)-1] {
+		switch m.Role {
+		case RoleUser:
+			req.ChatHistory = append(req.ChatHistory, &cohere.Message{Role: "USER", User: &cohere.ChatMessage{Message: m.Content}})
+		case RoleAssistant:
+			req.ChatHistory = append(req.ChatHistory, &cohere.Message{Role: "CHATBOT", Chatbot: &cohere.ChatMessage{Message: m.Content}})
+		default:
+			return nil, fmt.Errorf("role %q is not supported for %s", m.Role, i.Provider())
+		}
+	}
+	req.Message = messages[len(messages)-1].Content
+
+	return req, nil
+}
+
 func (i *InstructorCohere) chat(ctx context.Context, request interface{}, schema *Schema) (string, interface{}, error) {
 
 	req, ok := request.(*cohere.ChatRequest)
@@ -33,6 +82,11 @@ func (i *InstructorCohere) chat(ctx context.Context, request interface{}, schema
 		return "", nil, fmt.Errorf("invalid request type for %s client", i.Provider())
 	}
 
+	// the schema prompt is added to a copy, so that it is neither added to the
+	// caller's request nor repeated on retries
+	r := *req
+	req = &r
+
 	switch i.Mode() {
 	case ModeToolCall:
 		return i.chatToolCall(ctx, req, schema)
@@ -45,19 +99,8 @@ func (i *InstructorCohere) chat(ctx context.Context, request interface{}, schema
 
 func (i *InstructorCohere) chatToolCall(ctx context.Context, request *cohere.ChatRequest, schema *Schema) (string, *cohere.NonStreamedChatResponse, error) {
 
-	request.Tools = []*cohere.Tool{createCohereTools(schema)}
-
-	resp, err := i.Client.Chat(ctx, request)
-	if err != nil {
-		return "", nil, err
-	}
-
-	_ = resp
-
 	// TODO: implement
-
-	panic("tool call not implemented Cohere")
-
+	return "", nil, fmt.Errorf("mode '%s' is not implemented for %s", i.Mode(), i.Provider())
 }
 
 func (i *InstructorCohere) chatJSON(ctx context.Context, request *cohere.ChatRequest, schema *Schema) (string, *cohere.NonStreamedChatResponse, error) {
@@ -83,15 +126,88 @@ func (i *InstructorCohere) addOrConcatJSONSystemPrompt(request *cohere.ChatReque
 	}
 }
 
-func (i *InstructorCohere) emptyResponseWithUsageSum(usage *UsageSum) interface{} {
-	return &cohere.NonStreamedChatResponse{
-		Meta: &cohere.ApiMeta{
-			Tokens: &cohere.ApiMetaTokens{
-				InputTokens:  toPtr(float64(usage.InputTokens)),
-				OutputTokens: toPtr(float64(usage.OutputTokens)),
-			},
-		},
+func (i *InstructorCohere) reaskRequest(request interface{}, model string, output string, err error) (interface{}, error) {
+	req, ok := request.(*cohere.ChatRequest)
+	if !ok {
+		return nil, fmt.Errorf("invalid request type for %s client", i.Provider())
+	}
+	r := *req
+	if model != "" {
+		r.Model = toPtr(model)
+	}
+	// the message becomes history, and the reask the message
+	r.ChatHistory = append(r.ChatHistory[:len(r.ChatHistory):len(r.ChatHistory)],
+		&cohere.Message{Role: "USER", User: &cohere.ChatMessage{Message: r.Message}},
+		&cohere.Message{Role: "CHATBOT", Chatbot: &cohere.ChatMessage{Message: output}},
+	)
+	r.Message = reaskPrompt(err)
+	return &r, nil
+}
+
+func (i *InstructorCohere) requestModel(request interface{}) string {
+	var model *string
+	switch req := request.(type) {
+	case *cohere.ChatRequest:
+		model = req.Model
+	case *cohere.ChatStreamRequest:
+		model = req.Model
+	}
+	if model == nil {
+		return ""
+	}
+	return *model
+}
+
+func (i *InstructorCohere) finishReason(response interface{}) string {
+	resp, ok := response.(*cohere.NonStreamedChatResponse)
+	if !ok || resp == nil || resp.FinishReason == nil {
+		return ""
+	}
+	return string(*resp.FinishReason)
+}
+
+func (i *InstructorCohere) promptMessages(request interface{}) []promptMessage {
+	var (
+		preamble *string
+		history  []*cohere.Message
+		message  string
+	)
+	switch req := request.(type) {
+	case *cohere.ChatRequest:
+		preamble, history, message = req.Preamble, req.ChatHistory, req.Message
+	case *cohere.ChatStreamRequest:
+		preamble, history, message = req.Preamble, req.ChatHistory, req.Message
+	default:
+		return nil
+	}
+
+	messages := make([]promptMessage, 0, len(history)+2)
+	if preamble != nil {
+		messages = append(messages, promptMessage{Role: "SYSTEM", Content: *preamble})
+	}
+	for _, m := range history {
+		if m == nil {
+			continue
+		}
+		var content string
+		switch {
+		case m.User != nil:
+			content = m.User.Message
+		case m.Chatbot != nil:
+			content = m.Chatbot.Message
+		case m.System != nil:
+			content = m.System.Message
+		}
+		messages = append(messages, promptMessage{Role: m.Role, Content: content})
 	}
+	messages = append(messages, promptMessage{Role: "USER", Content: message})
+	return messages
+}
+
+func (i *InstructorCohere) emptyResponseWithUsageSum(usage *Usage) interface{} {
+	resp := &cohere.NonStreamedChatResponse{}
+	addCohereUsage(resp, usage)
+	return resp
 }
 
 func (i *InstructorCohere) emptyResponseWithResponseUsage(response interface{}) interface{} {
@@ -105,47 +221,44 @@ func (i *InstructorCohere) emptyResponseWithResponseUsage(response interface{})
 	}
 }
 
-func (i *InstructorCohere) addUsageSumToResponse(response interface{}, usage *UsageSum) (interface{}, error) {
+func (i *InstructorCohere) addUsageSumToResponse(response interface{}, usage *Usage) (interface{}, error) {
 	resp, ok := response.(*cohere.NonStreamedChatResponse)
 	if !ok {
 		return response, fmt.Errorf("internal type error: expected *cohere.NonStreamedChatResponse, got %T", response)
 	}
 
-	*resp.Meta.Tokens.InputTokens += float64(usage.InputTokens)
-	*resp.Meta.Tokens.OutputTokens += float64(usage.OutputTokens)
+	addCohereUsage(resp, usage)
 
 	return response, nil
 }
 
-func (i *InstructorCohere) countUsageFromResponse(response interface{}, usage *UsageSum) *UsageSum {
+func (i *InstructorCohere) countUsageFromResponse(response interface{}, usage *Usage) *Usage {
 	resp, ok := response.(*cohere.NonStreamedChatResponse)
-	if !ok {
+	if !ok || resp == nil || resp.Meta == nil || resp.Meta.Tokens == nil {
 		return usage
 	}
 
-	usage.InputTokens += int(*resp.Meta.Tokens.InputTokens)
-	usage.OutputTokens += int(*resp.Meta.Tokens.OutputTokens)
+	input := int(valueOf(resp.Meta.Tokens.InputTokens))
+	output := int(valueOf(resp.Meta.Tokens.OutputTokens))
+
+	usage.InputTokens += input
+	usage.OutputTokens += output
+	usage.TotalTokens += input + output
 
 	return usage
 }
 
-func createCohereTools(schema *Schema) *cohere.Tool {
-
-	tool := &cohere.Tool{
-		Name:                 "functions",
-		Description:          schema.Schema.Description,
-		ParameterDefinitions: make(map[string]*cohere.ToolParameterDefinitionsValue),
+func addCohereUsage(resp *cohere.NonStreamedChatResponse, usage *Usage) {
+	if resp.Meta == nil {
+		resp.Meta = &cohere.ApiMeta{}
 	}
-
-	for _, function := range schema.Functions {
-		parameterDefinition := &cohere.ToolParameterDefinitionsValue{
-			Description: toPtr(function.Description),
-			Type:        function.Parameters.Type,
-		}
-		tool.ParameterDefinitions[function.Name] = parameterDefinition
+	if resp.Meta.Tokens == nil {
+		resp.Meta.Tokens = &cohere.ApiMetaTokens{}
 	}
 
-	return tool
+	tokens := resp.Meta.Tokens
+	tokens.InputTokens = toPtr(valueOf(tokens.InputTokens) + float64(usage.InputTokens))
+	tokens.OutputTokens = toPtr(valueOf(tokens.OutputTokens) + float64(usage.OutputTokens))
 }
 
 func nilCohereRespWithUsage(resp *cohere.NonStreamedChatResponse) *cohere.NonStreamedChatResponse {
@@ -32,6 +32,9 @@ func (i *InstructorCohere) chatStream(ctx context.Context, request interface{},
 		return nil, fmt.Errorf("invalid request type for %s client", i.Provider())
 	}
 
+	r := *req
+	req = &r
+
 	switch i.Mode() {
 	case ModeJSON:
 		return i.chatJSONStream(ctx, req, schema)
@@ -79,11 +82,15 @@ func (i *InstructorCohere) createStream(ctx context.Context, request *cohere.Cha
 			case "stream-start":
 				continue
 			case "stream-end":
+				if message.StreamEnd != nil {
+					reportStreamUsage(ctx, i.countUsageFromResponse(message.StreamEnd.Response, &Usage{}))
+				}
 				return
 			case "text-generation":
 				ch <- message.TextGeneration.Text
 			default:
-				panic(errors.New("cohere streaming event type not supported by instructor: " + message.EventType))
+				// search results, citations and tool calls carry no output text
+				continue
 			}
 		}
 	}()
@@ -11,6 +11,7 @@ type InstructorCohere struct {
 	mode       Mode
 	maxRetries int
 	validate   bool
+	opts       Options
 }
 
 var _ Instructor = &InstructorCohere{}
@@ -25,6 +26,8 @@ func FromCohere(client *cohere.Client, opts ...Options) *InstructorCohere {
 		provider:   ProviderCohere,
 		mode:       *options.Mode,
 		maxRetries: *options.MaxRetries,
+		validate:   *options.validate,
+		opts:       options,
 	}
 	return i
 }
@@ -43,3 +46,7 @@ func (i *InstructorCohere) MaxRetries() int {
 func (i *InstructorCohere) Validate() bool {
 	return i.validate
 }
+
+func (i *InstructorCohere) options() Options {
+	return i.opts
+}
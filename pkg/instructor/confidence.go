@@ -0,0 +1,159 @@
+package instructor
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WithConfidence requests the log probabilities of the output tokens and maps
+// them onto the response. Metadata.Confidence then holds the confidence of
+// every leaf value per JSON path of the response, e.g. "$.address.city" or
+// "$.hobbies[0]": the joint probability of the tokens of the value.
+//
+// Only OpenAI clients in a JSON mode support it, as the provider returns no
+// log probabilities for tool call arguments, nor for reasoning models. Outputs
+// continued after a truncation report no confidence.
+func WithConfidence() Options {
+	return Options{confidence: toPtr(true)}
+}
+
+// LowConfidence returns the JSON paths of the values whose confidence is
+// below threshold, e.g. to send an extraction to human review.
+func (md *Metadata) LowConfidence(threshold float64) []string {
+	var paths []string
+	for path, confidence := range md.Confidence {
+		if confidence < threshold {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// scorer is implemented by clients mapping the log probabilities of a
+// response onto its JSON paths.
+type scorer interface {
+	confidence(response interface{}, schema *Schema) map[string]float64
+}
+
+// tokenLogprob is the log probability of the bytes of an output token.
+type tokenLogprob struct {
+	size    int
+	logprob float64
+}
+
+// valueSpan is the position of a leaf value in a JSON text.
+type valueSpan struct {
+	path       string
+	start, end int
+}
+
+// fieldConfidence returns the confidence of every leaf value of the JSON in
+// text, given the log probabilities of the tokens text was generated from.
+func fieldConfidence(text string, tokens []tokenLogprob) map[string]float64 {
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	// byte offset of every token in text
+	offsets := make([]int, len(tokens)+1)
+	for k, token := range tokens {
+		offsets[k+1] = offsets[k] + token.size
+	}
+
+	confidence := map[string]float64{}
+	for _, span := range jsonSpans(text) {
+		logprob := 0.0
+		for k, token := range tokens {
+			// tokens overlapping the value
+			if offsets[k] < span.end && offsets[k+1] > span.start {
+				logprob += token.logprob
+			}
+		}
+		confidence[span.path] = math.Exp(logprob)
+	}
+	return confidence
+}
+
+// jsonSpans returns the position of every leaf value of the first JSON object
+// or array of text.
+func jsonSpans(text string) []valueSpan {
+	base := strings.IndexAny(text, "{[")
+	if base < 0 {
+		return nil
+	}
+
+	type frame struct {
+		object  bool
+		key     string
+		index   int
+		wantKey bool
+	}
+
+	var stack []*frame
+	path := func() string {
+		var b strings.Builder
+		b.WriteString("$")
+		for _, f := range stack {
+			if f.object {
+				b.WriteString("." + f.key)
+			} else {
+				b.WriteString("[" + strconv.Itoa(f.index) + "]")
+			}
+		}
+		return b.String()
+	}
+	// advance moves the innermost container past the value just read
+	advance := func() {
+		if len(stack) == 0 {
+			return
+		}
+		if f := stack[len(stack)-1]; f.object {
+			f.wantKey = true
+		} else {
+			f.index++
+		}
+	}
+
+	var spans []valueSpan
+	dec := json.NewDecoder(strings.NewReader(text[base:]))
+	dec.UseNumber()
+	for {
+		start := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+
+		switch tok := tok.(type) {
+		case json.Delim:
+			switch tok {
+			case '{', '[':
+				stack = append(stack, &frame{object: tok == '{', wantKey: tok == '{'})
+			default:
+				stack = stack[:len(stack)-1]
+				advance()
+			}
+			if len(stack) == 0 {
+				return spans
+			}
+			continue
+		case string:
+			if f := stack[len(stack)-1]; f.object && f.wantKey {
+				f.key, f.wantKey = tok, false
+				continue
+			}
+		}
+
+		// skip the separators before the value
+		for start < len(text[base:]) && strings.IndexByte(" \t\r\n:,", text[base+start]) >= 0 {
+			start++
+		}
+		spans = append(spans, valueSpan{path: path(), start: base + start, end: base + int(dec.InputOffset())})
+		advance()
+	}
+	return spans
+}
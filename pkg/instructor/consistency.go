@@ -0,0 +1,379 @@
+package instructor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+const DefaultSamples = 5
+
+// SliceMerge is how the slices of the samples of a self-consistent extraction
+// are merged.
+type SliceMerge string
+
+const (
+	// SliceUnion keeps the elements found in any sample.
+	SliceUnion SliceMerge = "union"
+	// SliceIntersection keeps the elements found in every sample.
+	SliceIntersection SliceMerge = "intersection"
+)
+
+// SelfConsistency configures self-consistent extractions.
+type SelfConsistency struct {
+	// Samples is the number of extractions merged, DefaultSamples when zero.
+	Samples int
+	// Slices is how slices are merged, SliceUnion when empty.
+	Slices SliceMerge
+}
+
+// WithSelfConsistency samples the model several times and merges the samples
+// into a consensus response:
+//
+//	client := instructor.FromOpenAI(openai.NewClient(key),
+//		instructor.WithSelfConsistency(instructor.SelfConsistency{Samples: 5}),
+//	)
+//
+// Scalar fields get the value of the majority of the samples, the first one on
+// ties, and slices are merged as configured. Metadata.Agreement holds the
+// agreement of the samples per JSON path of the response: the share of the
+// samples voting for the value of a scalar, and the intersection over the
+// union of a slice.
+//
+// OpenAI clients sample the choices of a single call, requested with n, except
+// for reasoning models. Other clients make an extraction per sample. Samples
+// failing to decode or validate are left out of the vote. Self-consistent
+// extractions are neither cached nor coalesced.
+func WithSelfConsistency(consistency SelfConsistency) Options {
+	return Options{consistency: &consistency}
+}
+
+// sampler is implemented by clients getting several samples from one call.
+type sampler interface {
+	// sampleRequest returns request asking for n samples, or false when the
+	// request cannot ask for several.
+	sampleRequest(request interface{}, n int) (interface{}, bool, error)
+}
+
+type sampledKey struct{}
+
+type sampleSinkKey struct{}
+
+// sampleSink collects the output of every sample of a provider call.
+type sampleSink struct {
+	mu      sync.Mutex
+	resp    interface{}
+	outputs []string
+}
+
+func (s *sampleSink) record(resp interface{}, outputs []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resp, s.outputs = resp, outputs
+}
+
+// sampleSinkFromContext returns the sink the samples of provider calls made
+// with ctx are recorded into, if any.
+func sampleSinkFromContext(ctx context.Context) *sampleSink {
+	sink, _ := ctx.Value(sampleSinkKey{}).(*sampleSink)
+	return sink
+}
+
+// consistentChat merges samples of the extraction of request into response.
+func consistentChat(i Instructor, ctx context.Context, request interface{}, neutral *Request, response any, consistency *SelfConsistency) (interface{}, error) {
+	ptr := reflect.ValueOf(response)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() {
+		return nil, fmt.Errorf("self-consistency requires a non-nil pointer response, got %T", response)
+	}
+
+	n := consistency.Samples
+	if n <= 0 {
+		n = DefaultSamples
+	}
+
+	parent := metadataFromContext(ctx)
+	ctx = context.WithValue(ctx, sampledKey{}, true)
+
+	var md Metadata
+	var samples []reflect.Value
+	var resp interface{}
+	var err error
+	var sampled interface{}
+	var choices bool
+	if s, ok := i.(sampler); ok {
+		sampled, choices, err = s.sampleRequest(request, n)
+	}
+	switch {
+	case err != nil:
+	case choices:
+		samples, resp, md, err = sampleChoices(i, ctx, sampled, neutral, ptr.Elem().Type())
+	default:
+		samples, resp, md, err = sampleExtractions(i, ctx, request, neutral, ptr.Elem().Type(), n)
+	}
+
+	if err == nil {
+		var agreement map[string]float64
+		agreement, err = mergeSamples(i, samples, ptr, consistency.Slices)
+		md.Samples, md.Agreement = len(samples), agreement
+	}
+
+	if parent != nil {
+		*parent = md
+	}
+	return resp, err
+}
+
+// sampleChoices gets the samples from the choices of a single extraction of
+// req, asking for several.
+func sampleChoices(i Instructor, ctx context.Context, req interface{}, neutral *Request, t reflect.Type) ([]reflect.Value, interface{}, Metadata, error) {
+	var md Metadata
+
+	sink := &sampleSink{}
+	value := reflect.New(t)
+	resp, err := handleChat(i, context.WithValue(WithMetadata(ctx, &md), sampleSinkKey{}, sink), req, neutral, value.Interface())
+	if err != nil {
+		return nil, resp, md, err
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if sink.resp != resp {
+		// the response was not made by the sampled call, e.g. after escalation
+		return []reflect.Value{value}, resp, md, nil
+	}
+
+	var samples []reflect.Value
+	for _, output := range sink.outputs {
+		sample := reflect.New(t)
+		text := extractJSON(&output)
+		if err := json.Unmarshal([]byte(text), sample.Interface()); err != nil {
+			continue
+		}
+		if i.Validate() && validate.Struct(sample.Interface()) != nil {
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	if len(samples) == 0 {
+		samples = append(samples, value)
+	}
+	return samples, resp, md, nil
+}
+
+// sampleExtractions gets n samples from as many concurrent extractions.
+func sampleExtractions(i Instructor, ctx context.Context, request interface{}, neutral *Request, t reflect.Type, n int) ([]reflect.Value, interface{}, Metadata, error) {
+	type result struct {
+		value reflect.Value
+		resp  interface{}
+		md    Metadata
+		err   error
+	}
+
+	results := make([]result, n)
+	var wg sync.WaitGroup
+	for k := range results {
+		wg.Add(1)
+		go func(res *result) {
+			defer wg.Done()
+			res.value = reflect.New(t)
+			res.resp, res.err = handleChat(i, WithMetadata(ctx, &res.md), request, neutral, res.value.Interface())
+		}(&results[k])
+	}
+	wg.Wait()
+
+	var md Metadata
+	var samples []reflect.Value
+	var first *result
+	// usage of the extractions not returned
+	var others Usage
+	var err error
+	for k := range results {
+		res := &results[k]
+		md.Attempts += res.md.Attempts
+		md.Cost.add(res.md.Cost)
+		md.Usage.add(&res.md.Usage)
+		md.Usage.Attempts = append(md.Usage.Attempts, res.md.Usage.Attempts...)
+
+		if res.err == nil {
+			samples = append(samples, res.value)
+		}
+		if res.err == nil && first == nil {
+			first = res
+			continue
+		}
+		others.add(&res.md.Usage)
+		if res.err != nil && err == nil {
+			err = res.err
+		}
+	}
+
+	if first == nil {
+		md.Provider, md.Model = i.Provider(), i.requestModel(request)
+		return nil, i.emptyResponseWithUsageSum(&md.Usage), md, err
+	}
+
+	md.Provider, md.Model = first.md.Provider, first.md.Model
+	resp, err := i.addUsageSumToResponse(first.resp, &others)
+	if err != nil {
+		// escalated to another provider, the usage of the other samples is
+		// only in the Metadata
+		return samples, first.resp, md, nil
+	}
+	return samples, resp, md, nil
+}
+
+// mergeSamples merges samples into the value ptr points to, returning the
+// agreement per JSON path.
+func mergeSamples(i Instructor, samples []reflect.Value, ptr reflect.Value, slices SliceMerge) (map[string]float64, error) {
+	trees := make([]any, len(samples))
+	for k, sample := range samples {
+		b, err := json.Marshal(sample.Interface())
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &trees[k]); err != nil {
+			return nil, err
+		}
+	}
+
+	m := merger{slices: slices, agreement: map[string]float64{}}
+	b, err := json.Marshal(m.merge("$", trees))
+	if err != nil {
+		return nil, err
+	}
+
+	consensus := reflect.New(ptr.Elem().Type())
+	if err := json.Unmarshal(b, consensus.Interface()); err != nil {
+		return nil, err
+	}
+	if i.Validate() {
+		if err := validate.Struct(consensus.Interface()); err != nil {
+			return m.agreement, errors.Join(errors.New("consensus of the samples is invalid"), err)
+		}
+	}
+
+	ptr.Elem().Set(consensus.Elem())
+	return m.agreement, nil
+}
+
+// merger merges samples decoded into generic JSON values.
+type merger struct {
+	slices    SliceMerge
+	agreement map[string]float64
+}
+
+func (m *merger) merge(path string, values []any) any {
+	objects, arrays := 0, 0
+	for _, v := range values {
+		switch v.(type) {
+		case map[string]any:
+			objects++
+		case []any:
+			arrays++
+		}
+	}
+
+	switch {
+	case objects == len(values):
+		return m.mergeObjects(path, values)
+	case arrays == len(values):
+		return m.mergeSlices(path, values)
+	default:
+		return m.vote(path, values)
+	}
+}
+
+func (m *merger) mergeObjects(path string, values []any) any {
+	keys := map[string]bool{}
+	for _, v := range values {
+		for key := range v.(map[string]any) {
+			keys[key] = true
+		}
+	}
+
+	names := make([]string, 0, len(keys))
+	for key := range keys {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	merged := make(map[string]any, len(names))
+	for _, key := range names {
+		fields := make([]any, len(values))
+		for k, v := range values {
+			// a missing field votes for null
+			fields[k] = v.(map[string]any)[key]
+		}
+		merged[key] = m.merge(path+"."+key, fields)
+	}
+	return merged
+}
+
+func (m *merger) mergeSlices(path string, values []any) any {
+	var union []any
+	seen := map[string]bool{}
+	counts := map[string]int{}
+	for _, v := range values {
+		inSample := map[string]bool{}
+		for _, elem := range v.([]any) {
+			key := canonicalJSON(elem)
+			if !inSample[key] {
+				inSample[key] = true
+				counts[key]++
+			}
+			if !seen[key] {
+				seen[key] = true
+				union = append(union, elem)
+			}
+		}
+	}
+
+	merged := []any{}
+	for _, elem := range union {
+		if m.slices == SliceIntersection && counts[canonicalJSON(elem)] < len(values) {
+			continue
+		}
+		merged = append(merged, elem)
+	}
+
+	common := 0
+	for _, count := range counts {
+		if count == len(values) {
+			common++
+		}
+	}
+	m.agreement[path] = 1
+	if len(union) > 0 {
+		m.agreement[path] = float64(common) / float64(len(union))
+	}
+	return merged
+}
+
+func (m *merger) vote(path string, values []any) any {
+	counts := map[string]int{}
+	for _, v := range values {
+		counts[canonicalJSON(v)]++
+	}
+
+	var winner any
+	best := 0
+	for _, v := range values {
+		// strictly more votes, so the first value wins ties
+		if count := counts[canonicalJSON(v)]; count > best {
+			winner, best = v, count
+		}
+	}
+
+	m.agreement[path] = float64(best) / float64(len(values))
+	return winner
+}
+
+// canonicalJSON encodes a generic JSON value, with sorted object keys.
+func canonicalJSON(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
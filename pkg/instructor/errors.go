@@ -0,0 +1,18 @@
+package instructor
+
+// ExtractionError is returned when an extraction fails. It records the usage
+// and cost of every attempt made before the failure.
+type ExtractionError struct {
+	Err      error
+	Attempts int
+	Usage    Usage
+	Cost     Cost
+}
+
+func (e *ExtractionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExtractionError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,108 @@
+package instructor
+
+import (
+	"fmt"
+)
+
+// Escalation is a step of an escalation ladder, switching the extraction to a
+// stronger model or another client once the current one failed to produce a
+// valid response a number of times.
+type Escalation struct {
+	// After is the number of attempts, counted from the start of the
+	// extraction, that must have failed decoding or validation to take this step.
+	After int
+	// Model replaces the model of the request when set. Set it when switching
+	// to another provider, as model names differ between providers.
+	Model string
+	// Client replaces the client making the attempts when set. Switching to
+	// another provider requires a request given to Extract, see Extractor:
+	// native requests fail before the first attempt.
+	Client Instructor
+}
+
+// WithEscalation climbs an escalation ladder on failed attempts, so that the
+// expensive model is only paid for when the cheap one cannot satisfy the schema:
+//
+//	client := instructor.FromOpenAI(openai.NewClient(key),
+//		instructor.WithValidation(),
+//		instructor.WithMaxRetries(4),
+//		instructor.WithEscalation(instructor.Escalation{After: 2, Model: openai.GPT4o}),
+//	)
+//
+// The escalated attempt is sent the failed output and its error, so that the
+// stronger model can correct it. Steps share the retry budget of the client
+// and are not taken by streaming extractions.
+func WithEscalation(steps ...Escalation) Options {
+	return Options{escalation: steps}
+}
+
+// ladder tracks the escalation steps taken during an extraction.
+type ladder struct {
+	steps []Escalation
+	next  int
+	// neutral is the provider-neutral request of Extract, if any.
+	neutral *Request
+}
+
+func newLadder(steps []Escalation, neutral *Request) *ladder {
+	l := &ladder{steps: steps}
+	if neutral != nil {
+		n := *neutral
+		l.neutral = &n
+	}
+	return l
+}
+
+// check returns an error when a step switches away from the provider of
+// client while there is no neutral request to translate for it.
+func (l *ladder) check(client Instructor) error {
+	if l.neutral != nil {
+		return nil
+	}
+	for _, step := range l.steps {
+		if step.Client != nil && step.Client.Provider() != client.Provider() {
+			return fmt.Errorf("escalating from %s to %s requires a request given to Extract", client.Provider(), step.Client.Provider())
+		}
+	}
+	return nil
+}
+
+// escalate returns the client and request to make the next attempt with, once
+// failures attempts have failed. output and err are the ones of the last
+// attempt. Steps switching providers must have passed check.
+func (l *ladder) escalate(client Instructor, request interface{}, failures int, output string, err error) (Instructor, interface{}, bool, error) {
+	var step *Escalation
+	for l.next < len(l.steps) && failures >= l.steps[l.next].After {
+		step = &l.steps[l.next]
+		l.next++
+	}
+	if step == nil {
+		return client, request, false, nil
+	}
+
+	target := client
+	if step.Client != nil {
+		target = step.Client
+	}
+
+	if l.neutral != nil {
+		n := *l.neutral
+		n.Messages = append(n.Messages[:len(n.Messages):len(n.Messages)], AssistantMessage(output), UserMessage(reaskPrompt(err)))
+		if step.Model != "" {
+			n.Model = step.Model
+		}
+		// later steps keep the context of earlier ones
+		l.neutral = &n
+
+		req, err := target.nativeRequest(n)
+		return target, req, true, err
+	}
+
+	req, err := target.reaskRequest(request, step.Model, output, err)
+	return target, req, true, err
+}
+
+// reaskPrompt asks the model to correct a response that failed with err.
+func reaskPrompt(err error) string {
+	return fmt.Sprintf("Your previous response could not be used: %v\n\nRespond again with JSON that fixes the error.", err)
+}
@@ -0,0 +1,177 @@
+package instructor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Fallback extracts with an ordered chain of clients of any provider. When a
+// client fails, whether from a provider error, a timeout or exhausted
+// validation retries, the extraction falls through to the next one:
+//
+//	chain := instructor.NewFallback(
+//		instructor.FallbackEntry{Client: openaiClient, Model: openai.GPT4o, Timeout: 20 * time.Second},
+//		instructor.FallbackEntry{Client: anthropicClient, Model: "claude-3-5-sonnet-latest", Mode: instructor.ModeToolCall},
+//	)
+//
+//	resp, err := chain.Extract(ctx, request, &person)
+//	fmt.Println(resp.Provider, resp.Usage.TotalTokens)
+//
+// The response reports the provider and model of the final answer, and the
+// usage and cost of every attempt made along the chain.
+type Fallback struct {
+	entries []FallbackEntry
+}
+
+var _ Extractor = &Fallback{}
+
+// FallbackEntry is a client of a Fallback chain.
+type FallbackEntry struct {
+	Client Instructor
+	// Mode overrides the mode of Client when set. Only provider clients, such
+	// as those of FromOpenAI, can be overridden: extracting with another
+	// client, such as a Mock, fails.
+	Mode Mode
+	// Model overrides the model of the request when set, as model names
+	// differ between providers.
+	Model string
+	// Timeout bounds the extraction with Client, including its retries, when positive.
+	Timeout time.Duration
+}
+
+func NewFallback(entries ...FallbackEntry) *Fallback {
+	return &Fallback{entries: entries}
+}
+
+// FallbackError is returned when every client of a Fallback chain failed.
+type FallbackError struct {
+	Failures []FallbackFailure
+}
+
+// FallbackFailure is the failure of a single client of a Fallback chain.
+type FallbackFailure struct {
+	Provider Provider
+	Model    string
+	Err      error
+}
+
+func (e *FallbackError) Error() string {
+	msgs := make([]string, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		msgs = append(msgs, fmt.Sprintf("%s (%s): %v", f.Provider, f.Model, f.Err))
+	}
+	return "all fallback clients failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *FallbackError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		errs = append(errs, f.Err)
+	}
+	return errs
+}
+
+// Extract extracts responseType with the first client of the chain that succeeds.
+func (f *Fallback) Extract(ctx context.Context, request Request, responseType any) (*Response, error) {
+	if len(f.entries) == 0 {
+		return nil, errors.New("fallback chain has no clients")
+	}
+
+	parent := metadataFromContext(ctx)
+
+	var md Metadata
+	var failures []FallbackFailure
+	for n, entry := range f.entries {
+		if n > 0 {
+			// drop whatever a failed attempt decoded
+			resetResponse(responseType)
+		}
+
+		req := request
+		if entry.Model != "" {
+			req.Model = entry.Model
+		}
+
+		resp, err := f.extract(ctx, entry, req, responseType)
+		if resp != nil {
+			md.Provider = resp.Provider
+			md.Model = resp.Model
+			md.Attempts += resp.Attempts
+			md.Cost.add(resp.Cost)
+			md.Usage.add(&resp.Usage)
+			md.Usage.Attempts = append(md.Usage.Attempts, resp.Usage.Attempts...)
+			md.CacheHit = resp.CacheHit
+		}
+		if parent != nil {
+			*parent = md
+		}
+
+		if err == nil {
+			resp.Metadata = md
+			return resp, nil
+		}
+
+		failures = append(failures, FallbackFailure{Provider: entry.Client.Provider(), Model: req.Model, Err: err})
+
+		// the caller gave up, so there is nothing to fall through for
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return &Response{Metadata: md}, &FallbackError{Failures: failures}
+}
+
+func (f *Fallback) extract(ctx context.Context, entry FallbackEntry, request Request, responseType any) (*Response, error) {
+	if entry.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, entry.Timeout)
+		defer cancel()
+	}
+
+	client := entry.Client
+	if entry.Mode != "" {
+		var err error
+		if client, err = withMode(client, entry.Mode); err != nil {
+			return nil, err
+		}
+	}
+
+	return client.Extract(ctx, request, responseType)
+}
+
+// withMode returns a copy of i extracting with mode.
+func withMode(i Instructor, mode Mode) (Instructor, error) {
+	switch c := i.(type) {
+	case *InstructorOpenAI:
+		cp := *c
+		cp.mode = mode
+		return &cp, nil
+	case *InstructorAnthropic:
+		cp := *c
+		cp.mode = mode
+		return &cp, nil
+	case *InstructorGoogle:
+		cp := *c
+		cp.mode = mode
+		return &cp, nil
+	case *InstructorCohere:
+		cp := *c
+		cp.mode = mode
+		return &cp, nil
+	}
+	// other clients, such as Mock, share state that must not be copied
+	return nil, fmt.Errorf("the mode of a %T client cannot be overridden", i)
+}
+
+// resetResponse sets the value responseType points to back to its zero value.
+func resetResponse(responseType any) {
+	v := reflect.ValueOf(responseType)
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"google.golang.org/genai"
 )
@@ -25,6 +26,57 @@ func (i *InstructorGoogle) CreateChatCompletion(
 	return response, nil
 }
 
+func (i *InstructorGoogle) Extract(ctx context.Context, request Request, responseType any) (*Response, error) {
+	return extract(i, ctx, request, responseType)
+}
+
+func (i *InstructorGoogle) nativeRequest(request Request) (interface{}, error) {
+	req := GoogleRequest{
+		Model: request.Model,
+	}
+	if system := request.system(); system != "" {
+		req.SystemInstruction = genai.NewContentFromText(system, genai.RoleUser)
+	}
+	if request.Temperature != nil || request.MaxTokens > 0 {
+		req.GenerationConfig = &genai.GenerationConfig{
+			Temperature:     request.Temperature,
+			MaxOutputTokens: int32(request.MaxTokens),
+		}
+	}
+
+	for _, m := range request.Messages {
+		var role genai.Role
+		switch m.Role {
+		case RoleSystem:
+			continue
+		case RoleUser:
+			role = genai.RoleUser
+		case RoleAssistant:
+			role = genai.RoleModel
+		default:
+			return nil, fmt.Errorf("role %q is not supported for %s", m.Role, i.Provider())
+		}
+
+		content := &genai.Content{Role: string(role)}
+		for _, img := range m.Images {
+			if err := img.validate(); err != nil {
+				return nil, err
+			}
+			if len(img.Data) > 0 {
+				content.Parts = append(content.Parts, genai.NewPartFromBytes(img.Data, img.MediaType))
+			} else {
+				content.Parts = append(content.Parts, genai.NewPartFromURI(img.URL, img.mediaType()))
+			}
+		}
+		if m.Content != "" {
+			content.Parts = append(content.Parts, genai.NewPartFromText(m.Content))
+		}
+		req.Contents = append(req.Contents, content)
+	}
+
+	return req, nil
+}
+
 func (i *InstructorGoogle) chat(ctx context.Context, request interface{}, schema *Schema) (string, interface{}, error) {
 	req, ok := request.(GoogleRequest)
 	if !ok {
@@ -48,13 +100,9 @@ func (i *InstructorGoogle) chat(ctx context.Context, request interface{}, schema
 
 func (i *InstructorGoogle) chatToolCall(ctx context.Context, request *GoogleRequest, schema *Schema, strict bool) (string, *GoogleResponse, error) {
 	tools := createGoogleTools(schema, strict)
-	if request.GenerationConfig == nil {
-		request.GenerationConfig = &genai.GenerationConfig{}
-	}
-	resp, err := i.Models.GenerateContent(ctx, request.Model, request.Contents, &genai.GenerateContentConfig{
-		Tools:          tools,
-		SafetySettings: request.SafetySettings,
-	})
+	config := request.config()
+	config.Tools = tools
+	resp, err := i.Models.GenerateContent(ctx, request.Model, request.Contents, config)
 	if err != nil {
 		return "", nil, err
 	}
@@ -106,14 +154,7 @@ func (i *InstructorGoogle) chatToolCall(ctx context.Context, request *GoogleRequ
 func (i *InstructorGoogle) chatJSON(ctx context.Context, request *GoogleRequest, schema *Schema, strict bool) (string, *GoogleResponse, error) {
 	structName := schema.NameFromRef()
 	request.Contents = prependGoogleContents(request.Contents, *createGoogleJSONMessage(schema))
-	if strict {
-		if request.GenerationConfig == nil {
-			request.GenerationConfig = &genai.GenerationConfig{}
-		}
-	}
-	resp, err := i.Models.GenerateContent(ctx, request.Model, request.Contents, &genai.GenerateContentConfig{
-		SafetySettings: request.SafetySettings,
-	})
+	resp, err := i.Models.GenerateContent(ctx, request.Model, request.Contents, request.config())
 	if err != nil {
 		return "", nil, err
 	}
@@ -121,29 +162,16 @@ func (i *InstructorGoogle) chatJSON(ctx context.Context, request *GoogleRequest,
 		Candidates:    resp.Candidates,
 		UsageMetadata: resp.UsageMetadata,
 	}
-	text := ""
-	if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
-		for _, part := range resp.Candidates[0].Content.Parts {
-			if part.Text != "" {
-				text = part.Text
-				break
-			}
-		}
-	}
+	text := googleTextOutput(resp.Candidates)
 	if strict {
-		resMap := make(map[string]any)
-		_ = json.Unmarshal([]byte(text), &resMap)
-		cleanedText, _ := json.Marshal(resMap[structName])
-		text = string(cleanedText)
+		text = unwrapJSON(text, structName)
 	}
 	return text, googleResp, nil
 }
 
 func (i *InstructorGoogle) chatJSONSchema(ctx context.Context, request *GoogleRequest, schema *Schema) (string, *GoogleResponse, error) {
 	request.Contents = prependGoogleContents(request.Contents, *createGoogleJSONMessage(schema))
-	resp, err := i.Models.GenerateContent(ctx, request.Model, request.Contents, &genai.GenerateContentConfig{
-		SafetySettings: request.SafetySettings,
-	})
+	resp, err := i.Models.GenerateContent(ctx, request.Model, request.Contents, request.config())
 	if err != nil {
 		return "", nil, err
 	}
@@ -151,15 +179,7 @@ func (i *InstructorGoogle) chatJSONSchema(ctx context.Context, request *GoogleRe
 		Candidates:    resp.Candidates,
 		UsageMetadata: resp.UsageMetadata,
 	}
-	text := ""
-	if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
-		for _, part := range resp.Candidates[0].Content.Parts {
-			if part.Text != "" {
-				text = part.Text
-				break
-			}
-		}
-	}
+	text := googleTextOutput(resp.Candidates)
 	return text, googleResp, nil
 }
 
@@ -176,17 +196,25 @@ func createGoogleJSONMessage(schema *Schema) *genai.Content {
 }
 
 func createGoogleTools(schema *Schema, strict bool) []*genai.Tool {
-	// TODO: Convert schema.Schema.Properties to map[string]*genai.Schema if needed
+	name := schema.NameFromRef()
+	def := schema.Definitions[name]
+
+	parameters := map[string]any{
+		"type":       "object",
+		"properties": def.Properties,
+		"required":   def.Required,
+	}
+	if len(schema.Definitions) > 1 {
+		// nested types are referenced as #/$defs/Name
+		parameters["$defs"] = schema.Definitions
+	}
+
 	tool := &genai.Tool{
 		FunctionDeclarations: []*genai.FunctionDeclaration{
 			{
-				Name:        schema.NameFromRef(),
-				Description: schema.Description,
-				Parameters: &genai.Schema{
-					Type:       "object",
-					Properties: map[string]*genai.Schema{}, // TODO: convert from schema.Schema.Properties
-					Required:   []string{},                 // TODO: convert from schema.Schema.Required
-				},
+				Name:                 name,
+				Description:          def.Description,
+				ParametersJsonSchema: parameters,
 			},
 		},
 	}
@@ -196,3 +224,34 @@ func createGoogleTools(schema *Schema, strict bool) []*genai.Tool {
 func prependGoogleContents(contents []*genai.Content, content genai.Content) []*genai.Content {
 	return append([]*genai.Content{&content}, contents...)
 }
+
+// googleTextOutput returns the first text part of the first candidate,
+// skipping the thought parts of thinking models.
+func googleTextOutput(candidates []*genai.Candidate) string {
+	if len(candidates) == 0 || candidates[0].Content == nil {
+		return ""
+	}
+	for _, part := range candidates[0].Content.Parts {
+		if part.Text != "" && !part.Thought {
+			return part.Text
+		}
+	}
+	return ""
+}
+
+// reasoning returns the thought parts of the response, concatenated, returned
+// when the thinking config includes thoughts.
+func (i *InstructorGoogle) reasoning(response interface{}) string {
+	resp, ok := response.(*GoogleResponse)
+	if !ok || resp == nil || len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return ""
+	}
+
+	var reasoning strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if part.Thought {
+			reasoning.WriteString(part.Text)
+		}
+	}
+	return reasoning.String()
+}
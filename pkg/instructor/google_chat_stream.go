@@ -14,6 +14,8 @@ const (
 	ErrIteratorDone = "iterator done"
 )
 
+// CreateChatCompletionStream streams the items of responseType extracted from
+// the response, each sent as its JSON.
 func (i *InstructorGoogle) CreateChatCompletionStream(
 	ctx context.Context,
 	request GoogleRequest,
@@ -30,8 +32,17 @@ func (i *InstructorGoogle) CreateChatCompletionStream(
 	go func() {
 		defer close(stringCh)
 		for msg := range ch {
-			if str, ok := msg.(string); ok {
-				stringCh <- str
+			b, err := json.Marshal(msg)
+			if err != nil {
+				continue
+			}
+			select {
+			case stringCh <- string(b):
+			case <-ctx.Done():
+				// let the parser finish once the consumer gave up
+				for range ch {
+				}
+				return
 			}
 		}
 	}()
@@ -69,69 +80,16 @@ func (i *InstructorGoogle) chatStreamToolCall(ctx context.Context, request *Goog
 }
 
 func (i *InstructorGoogle) chatStreamJSON(ctx context.Context, request *GoogleRequest, schema *Schema, strict bool) (<-chan string, error) {
-	structName := schema.NameFromRef()
-
-	// Prepend JSON instruction message
-	request.Contents = prependGoogleContents(request.Contents, *createGoogleJSONMessage(schema))
-
-	// Create config (do not use GenerationConfig field)
-	config := &genai.GenerateContentConfig{
-		SafetySettings: request.SafetySettings,
-	}
-
-	// Start streaming
-	iter := i.Models.GenerateContentStream(ctx, request.Model, request.Contents, config)
-
-	ch := make(chan string)
-
-	go func() {
-		defer close(ch)
-		var fullText string
-
-		// Use the iterator with a yield function
-		iter(func(resp *genai.GenerateContentResponse, err error) bool {
-			if err != nil {
-				// Handle end of stream or error
-				if err.Error() == ErrIteratorDone {
-					// Process the complete response
-					if strict {
-						resMap := make(map[string]any)
-						_ = json.Unmarshal([]byte(fullText), &resMap)
-						if cleanedText, err := json.Marshal(resMap[structName]); err == nil {
-							fullText = string(cleanedText)
-						}
-					}
-					ch <- fullText
-				}
-				return false // Stop iteration
-			}
-
-			// Extract text from response
-			if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
-				for _, part := range resp.Candidates[0].Content.Parts {
-					if part.Text != "" {
-						fullText += part.Text
-						ch <- part.Text
-					}
-				}
-			}
-			return true // Continue iteration
-		})
-	}()
-
-	return ch, nil
+	// Streamed items are wrapped in an anonymous object, so there is no named
+	// root for strict mode to unwrap: both modes stream the same way.
+	return i.chatStreamJSONSchema(ctx, request, schema)
 }
 
 func (i *InstructorGoogle) chatStreamJSONSchema(ctx context.Context, request *GoogleRequest, schema *Schema) (<-chan string, error) {
 	request.Contents = prependGoogleContents(request.Contents, *createGoogleJSONMessage(schema))
 
-	// Create config (do not use GenerationConfig field)
-	config := &genai.GenerateContentConfig{
-		SafetySettings: request.SafetySettings,
-	}
-
 	// Start streaming
-	iter := i.Models.GenerateContentStream(ctx, request.Model, request.Contents, config)
+	iter := i.Models.GenerateContentStream(ctx, request.Model, request.Contents, request.config())
 
 	ch := make(chan string)
 
@@ -148,10 +106,14 @@ func (i *InstructorGoogle) chatStreamJSONSchema(ctx context.Context, request *Go
 				return false // Stop iteration on error
 			}
 
+			if resp.UsageMetadata != nil {
+				reportStreamUsage(ctx, i.countUsageFromResponse(&GoogleResponse{UsageMetadata: resp.UsageMetadata}, &Usage{}))
+			}
+
 			// Extract text from response
 			if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
 				for _, part := range resp.Candidates[0].Content.Parts {
-					if part.Text != "" {
+					if part.Text != "" && !part.Thought {
 						ch <- part.Text
 					}
 				}
@@ -1,6 +1,8 @@
 package instructor
 
 import (
+	"fmt"
+
 	"google.golang.org/genai"
 )
 
@@ -11,6 +13,7 @@ type InstructorGoogle struct {
 	mode       Mode
 	maxRetries int
 	validate   bool
+	opts       Options
 }
 
 func FromGoogle(client *genai.Client, opts ...Options) *InstructorGoogle {
@@ -23,6 +26,7 @@ func FromGoogle(client *genai.Client, opts ...Options) *InstructorGoogle {
 		mode:       *options.Mode,
 		maxRetries: *options.MaxRetries,
 		validate:   *options.validate,
+		opts:       options,
 	}
 	return i
 }
@@ -43,12 +47,48 @@ func (i *InstructorGoogle) Validate() bool {
 	return i.validate
 }
 
+func (i *InstructorGoogle) options() Options {
+	return i.opts
+}
+
 // GoogleRequest represents a request to the Google AI API
 type GoogleRequest struct {
 	Model            string                  `json:"model"`
 	Contents         []*genai.Content        `json:"contents"`
 	GenerationConfig *genai.GenerationConfig `json:"generationConfig,omitempty"`
 	SafetySettings   []*genai.SafetySetting  `json:"safetySettings,omitempty"`
+	// SystemInstruction is the system prompt.
+	SystemInstruction *genai.Content `json:"systemInstruction,omitempty"`
+}
+
+// config returns the content generation config of the request.
+func (r *GoogleRequest) config() *genai.GenerateContentConfig {
+	config := &genai.GenerateContentConfig{
+		SystemInstruction: r.SystemInstruction,
+		SafetySettings:    r.SafetySettings,
+	}
+
+	if gc := r.GenerationConfig; gc != nil {
+		config.Temperature = gc.Temperature
+		config.TopP = gc.TopP
+		config.TopK = gc.TopK
+		config.CandidateCount = gc.CandidateCount
+		config.MaxOutputTokens = gc.MaxOutputTokens
+		config.StopSequences = gc.StopSequences
+		config.ResponseLogprobs = gc.ResponseLogprobs
+		config.Logprobs = gc.Logprobs
+		config.PresencePenalty = gc.PresencePenalty
+		config.FrequencyPenalty = gc.FrequencyPenalty
+		config.Seed = gc.Seed
+		if gc.ThinkingConfig != nil {
+			config.ThinkingConfig = &genai.ThinkingConfig{
+				IncludeThoughts: gc.ThinkingConfig.IncludeThoughts,
+				ThinkingBudget:  gc.ThinkingConfig.ThinkingBudget,
+			}
+		}
+	}
+
+	return config
 }
 
 // GoogleResponse represents a response from the Google AI API
@@ -57,14 +97,63 @@ type GoogleResponse struct {
 	UsageMetadata *genai.GenerateContentResponseUsageMetadata `json:"usageMetadata,omitempty"`
 }
 
-func (i *InstructorGoogle) emptyResponseWithUsageSum(usage *UsageSum) interface{} {
-	return &GoogleResponse{
-		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
-			PromptTokenCount:     int32(usage.InputTokens),
-			CandidatesTokenCount: int32(usage.OutputTokens),
-			TotalTokenCount:      int32(usage.TotalTokens),
-		},
+func (i *InstructorGoogle) requestModel(request interface{}) string {
+	req, ok := request.(GoogleRequest)
+	if !ok {
+		return ""
+	}
+	return req.Model
+}
+
+func (i *InstructorGoogle) reaskRequest(request interface{}, model string, output string, err error) (interface{}, error) {
+	req, ok := request.(GoogleRequest)
+	if !ok {
+		return nil, fmt.Errorf("invalid request type for %s client", i.Provider())
+	}
+	if model != "" {
+		req.Model = model
+	}
+	req.Contents = append(req.Contents[:len(req.Contents):len(req.Contents)],
+		genai.NewContentFromText(output, genai.RoleModel),
+		genai.NewContentFromText(reaskPrompt(err), genai.RoleUser),
+	)
+	return req, nil
+}
+
+func (i *InstructorGoogle) finishReason(response interface{}) string {
+	resp, ok := response.(*GoogleResponse)
+	if !ok || resp == nil || len(resp.Candidates) == 0 || resp.Candidates[0] == nil {
+		return ""
+	}
+	return string(resp.Candidates[0].FinishReason)
+}
+
+func (i *InstructorGoogle) promptMessages(request interface{}) []promptMessage {
+	req, ok := request.(GoogleRequest)
+	if !ok {
+		return nil
+	}
+
+	messages := make([]promptMessage, 0, len(req.Contents))
+	for _, c := range req.Contents {
+		if c == nil {
+			continue
+		}
+		content := ""
+		for _, part := range c.Parts {
+			if part != nil {
+				content += part.Text
+			}
+		}
+		messages = append(messages, promptMessage{Role: c.Role, Content: content})
 	}
+	return messages
+}
+
+func (i *InstructorGoogle) emptyResponseWithUsageSum(usage *Usage) interface{} {
+	resp := &GoogleResponse{}
+	addGoogleUsage(resp, usage)
+	return resp
 }
 
 func (i *InstructorGoogle) emptyResponseWithResponseUsage(response interface{}) interface{} {
@@ -78,36 +167,49 @@ func (i *InstructorGoogle) emptyResponseWithResponseUsage(response interface{})
 	}
 }
 
-func (i *InstructorGoogle) addUsageSumToResponse(response interface{}, usage *UsageSum) (interface{}, error) {
+func (i *InstructorGoogle) addUsageSumToResponse(response interface{}, usage *Usage) (interface{}, error) {
 	resp, ok := response.(*GoogleResponse)
 	if !ok || resp == nil {
 		return response, nil
 	}
 
-	if resp.UsageMetadata == nil {
-		resp.UsageMetadata = &genai.GenerateContentResponseUsageMetadata{}
-	}
-
-	resp.UsageMetadata.PromptTokenCount = int32(usage.InputTokens)
-	resp.UsageMetadata.CandidatesTokenCount = int32(usage.OutputTokens)
-	resp.UsageMetadata.TotalTokenCount = int32(usage.TotalTokens)
+	addGoogleUsage(resp, usage)
 
 	return resp, nil
 }
 
-func (i *InstructorGoogle) countUsageFromResponse(response interface{}, usage *UsageSum) *UsageSum {
+func (i *InstructorGoogle) countUsageFromResponse(response interface{}, usage *Usage) *Usage {
 	resp, ok := response.(*GoogleResponse)
 	if !ok || resp == nil || resp.UsageMetadata == nil {
 		return usage
 	}
 
-	usage.InputTokens = int(resp.UsageMetadata.PromptTokenCount)
-	usage.OutputTokens = int(resp.UsageMetadata.CandidatesTokenCount)
-	usage.TotalTokens = int(resp.UsageMetadata.TotalTokenCount)
+	md := resp.UsageMetadata
+
+	// Gemini counts thoughts apart from the candidates and tool-use prompts
+	// apart from the prompt.
+	usage.InputTokens += int(md.PromptTokenCount + md.ToolUsePromptTokenCount)
+	usage.OutputTokens += int(md.CandidatesTokenCount + md.ThoughtsTokenCount)
+	usage.TotalTokens += int(md.TotalTokenCount)
+	usage.CachedInputTokens += int(md.CachedContentTokenCount)
+	usage.ReasoningTokens += int(md.ThoughtsTokenCount)
 
 	return usage
 }
 
+func addGoogleUsage(resp *GoogleResponse, usage *Usage) {
+	if resp.UsageMetadata == nil {
+		resp.UsageMetadata = &genai.GenerateContentResponseUsageMetadata{}
+	}
+
+	md := resp.UsageMetadata
+	md.PromptTokenCount += int32(usage.InputTokens)
+	md.CandidatesTokenCount += int32(usage.OutputTokens - usage.ReasoningTokens)
+	md.ThoughtsTokenCount += int32(usage.ReasoningTokens)
+	md.TotalTokenCount += int32(usage.TotalTokens)
+	md.CachedContentTokenCount += int32(usage.CachedInputTokens)
+}
+
 func nilGoogleRespWithUsage(resp *GoogleResponse) *GoogleResponse {
 	if resp == nil {
 		return &GoogleResponse{}
@@ -0,0 +1,189 @@
+package instructor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Hedge configures hedged extractions.
+type Hedge struct {
+	// Delay is how long the first extraction may run before the hedged one is
+	// launched. A first extraction failing earlier launches it right away.
+	Delay time.Duration
+	// Client makes the hedged extraction, the same client when nil. Hedging
+	// with another provider requires a request given to Extract, see
+	// Extractor.
+	Client Instructor
+	// Model replaces the model of the hedged extraction when set. It only
+	// applies to requests given to Extract.
+	Model string
+}
+
+// WithHedging cuts tail latency by launching a second extraction when the
+// first one has not completed after a delay:
+//
+//	client := instructor.FromOpenAI(openai.NewClient(key),
+//		instructor.WithHedging(instructor.Hedge{Delay: 2 * time.Second}),
+//	)
+//
+// Whichever extraction returns a valid response first wins and is returned at
+// once, canceling the other. The response and Metadata report the usage and
+// cost of the winner and of an extraction that failed before it, while the
+// attempts of a canceled one are only counted by budgets and cost trackers. A
+// first extraction failing before the delay launches the hedged one right
+// away. Hooks see a single extraction with the attempts of both. The hedged extraction is neither cached nor coalesced, and streaming
+// extractions are not hedged.
+func WithHedging(hedge Hedge) Options {
+	return Options{hedge: &hedge}
+}
+
+type hedgedKey struct{}
+
+// hedgedExtractionKey marks the context of the hedged extraction, which is
+// neither cached nor coalesced: it would join the flight of the extraction it
+// races.
+type hedgedExtractionKey struct{}
+
+// hedgeResult is the outcome of one of the extractions of a hedged extraction.
+type hedgeResult struct {
+	client Instructor
+	value  reflect.Value
+	resp   interface{}
+	md     Metadata
+	err    error
+}
+
+// hedgedChat races the extraction of request by i against a hedged one. The
+// extraction hooks are called once for both, which only report their attempts.
+func hedgedChat(i Instructor, ctx context.Context, request interface{}, neutral *Request, response any, hedge *Hedge) (interface{}, error) {
+	ptr := reflect.ValueOf(response)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() {
+		return nil, fmt.Errorf("hedging requires a non-nil pointer response, got %T", response)
+	}
+
+	client, req, err := hedgeRequest(i, request, neutral, hedge)
+	if err != nil {
+		return nil, err
+	}
+
+	t := reflect.TypeOf(response)
+	schema, err := NewSchema(t)
+	if err != nil {
+		return nil, err
+	}
+
+	hooks := i.options().allHooks()
+	info := newExtractionInfo(i, request, t, schema)
+	ctx = hooks.extractionStart(ctx, info)
+
+	parent := metadataFromContext(ctx)
+
+	raceCtx, cancel := context.WithCancel(context.WithValue(ctx, hedgedKey{}, true))
+	// the loser is canceled once the winner returns
+	defer cancel()
+
+	results := make(chan hedgeResult, 2)
+	run := func(ctx context.Context, c Instructor, r interface{}) {
+		res := hedgeResult{client: c, value: reflect.New(ptr.Elem().Type())}
+		res.resp, res.err = handleChat(c, WithMetadata(ctx, &res.md), r, neutral, res.value.Interface())
+		results <- res
+	}
+
+	go run(raceCtx, i, request)
+	launched := 1
+	launch := func() {
+		if launched == 1 {
+			go run(context.WithValue(raceCtx, hedgedExtractionKey{}, true), client, req)
+			launched++
+		}
+	}
+
+	timer := time.NewTimer(hedge.Delay)
+	defer timer.Stop()
+
+	var md Metadata
+	var winner *hedgeResult
+	// usage of the extractions not returned
+	var others Usage
+	var first error
+	for received := 0; received < launched && winner == nil; {
+		select {
+		case <-timer.C:
+			launch()
+		case res := <-results:
+			received++
+			md.Attempts += res.md.Attempts
+			md.Cost.add(res.md.Cost)
+			md.Usage.add(&res.md.Usage)
+			md.Usage.Attempts = append(md.Usage.Attempts, res.md.Usage.Attempts...)
+
+			if res.err == nil {
+				winner = &res
+				continue
+			}
+			others.add(&res.md.Usage)
+			if first == nil {
+				first = res.err
+			}
+			// a failed first extraction does not wait for the delay
+			launch()
+		}
+	}
+
+	result := ExtractionResult{Attempts: md.Attempts, Usage: md.Usage, Cost: md.Cost}
+
+	if winner == nil {
+		md.Provider, md.Model = i.Provider(), i.requestModel(request)
+		if parent != nil {
+			*parent = md
+		}
+
+		// the extractions return their own usage with the cause
+		var extractionErr *ExtractionError
+		if errors.As(first, &extractionErr) {
+			first = extractionErr.Err
+		}
+		result.Err = first
+		hooks.extractionEnd(ctx, info, result)
+		return i.emptyResponseWithUsageSum(&md.Usage), &ExtractionError{Err: first, Attempts: md.Attempts, Usage: md.Usage, Cost: md.Cost}
+	}
+
+	ptr.Elem().Set(winner.value.Elem())
+
+	md.Provider, md.Model = winner.md.Provider, winner.md.Model
+	md.CacheHit, md.Coalesced = winner.md.CacheHit, winner.md.Coalesced
+	if parent != nil {
+		*parent = md
+	}
+
+	result.CacheHit, result.Coalesced = md.CacheHit, md.Coalesced
+	hooks.extractionEnd(ctx, info, result)
+
+	// add the usage of the failed extraction to the native response of the winner
+	return winner.client.addUsageSumToResponse(winner.resp, &others)
+}
+
+// hedgeRequest returns the client and request of the hedged extraction.
+func hedgeRequest(i Instructor, request interface{}, neutral *Request, hedge *Hedge) (Instructor, interface{}, error) {
+	client := i
+	if hedge.Client != nil {
+		client = hedge.Client
+	}
+
+	if neutral != nil && (hedge.Client != nil || hedge.Model != "") {
+		n := *neutral
+		if hedge.Model != "" {
+			n.Model = hedge.Model
+		}
+		req, err := client.nativeRequest(n)
+		return client, req, err
+	}
+
+	if client.Provider() != i.Provider() {
+		return nil, nil, fmt.Errorf("hedging %s with %s requires a request given to Extract", i.Provider(), client.Provider())
+	}
+	return client, request, nil
+}
@@ -0,0 +1,113 @@
+package instructor
+
+import (
+	"context"
+	"reflect"
+)
+
+// Hooks observes the lifecycle of extractions made by an Instructor client.
+// Register them with WithHooks. Implementations must be safe for concurrent use.
+type Hooks interface {
+	// ExtractionStart is called once before the first attempt. The returned
+	// context is used for the remainder of the extraction.
+	ExtractionStart(ctx context.Context, info ExtractionInfo) context.Context
+
+	// AttemptStart is called before every provider call. The returned context
+	// is passed to the provider call and to the matching AttemptEnd.
+	AttemptStart(ctx context.Context, info ExtractionInfo, attempt int) context.Context
+
+	// AttemptEnd is called after every provider call with its outcome.
+	AttemptEnd(ctx context.Context, info ExtractionInfo, result AttemptResult)
+
+	// ExtractionEnd is called once when the extraction returns.
+	ExtractionEnd(ctx context.Context, info ExtractionInfo, result ExtractionResult)
+}
+
+// ExtractionInfo describes an extraction independently of its provider.
+type ExtractionInfo struct {
+	Provider   Provider
+	Model      string
+	Mode       Mode
+	Schema     string
+	MaxRetries int
+	Stream     bool
+
+	client       Instructor
+	request      interface{}
+	responseType reflect.Type
+}
+
+// AttemptResult describes the outcome of a single provider call.
+type AttemptResult struct {
+	Attempt      int
+	Usage        Usage
+	Cost         Cost
+	FinishReason string
+
+	// Output is the raw text returned by the model.
+	Output string
+	// JSON is the JSON extracted from Output.
+	JSON string
+
+	// Err is set when the provider call itself failed; the extraction ends.
+	Err error
+	// DecodeErr is set when JSON could not be unmarshalled into the response type.
+	DecodeErr error
+	// ValidationErr is set when the decoded response failed validation.
+	ValidationErr error
+}
+
+// ExtractionResult describes the outcome of a whole extraction.
+type ExtractionResult struct {
+	Attempts int
+	Usage    Usage
+	Cost     Cost
+	CacheHit bool
+	// Coalesced is set when the extraction shared the result of an identical one.
+	Coalesced bool
+	// Err is the cause of a failed extraction, which callers get wrapped in
+	// an *ExtractionError.
+	Err error
+}
+
+type hookList []Hooks
+
+func (h hookList) extractionStart(ctx context.Context, info ExtractionInfo) context.Context {
+	for _, hook := range h {
+		ctx = hook.ExtractionStart(ctx, info)
+	}
+	return ctx
+}
+
+func (h hookList) attemptStart(ctx context.Context, info ExtractionInfo, attempt int) context.Context {
+	for _, hook := range h {
+		ctx = hook.AttemptStart(ctx, info, attempt)
+	}
+	return ctx
+}
+
+func (h hookList) attemptEnd(ctx context.Context, info ExtractionInfo, result AttemptResult) {
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i].AttemptEnd(ctx, info, result)
+	}
+}
+
+func (h hookList) extractionEnd(ctx context.Context, info ExtractionInfo, result ExtractionResult) {
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i].ExtractionEnd(ctx, info, result)
+	}
+}
+
+func newExtractionInfo(i Instructor, request interface{}, responseType reflect.Type, schema *Schema) ExtractionInfo {
+	return ExtractionInfo{
+		Provider:   i.Provider(),
+		Model:      i.requestModel(request),
+		Mode:       i.Mode(),
+		Schema:     schema.Name(),
+		MaxRetries: i.MaxRetries(),
+
+		client:       i,
+		request:      request,
+		responseType: responseType,
+	}
+}
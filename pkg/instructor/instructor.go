@@ -6,7 +6,9 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
-var validate *validator.Validate
+// validate validates every response. It caches the struct metadata of the
+// response types, and is safe for concurrent use by extractions.
+var validate = validator.New()
 
 type Instructor interface {
 	Provider() Provider
@@ -14,6 +16,17 @@ type Instructor interface {
 	MaxRetries() int
 	Validate() bool
 
+	Extractor
+
+	options() Options
+
+	// Provider-neutral requests
+
+	nativeRequest(request Request) (interface{}, error)
+	// reaskRequest returns a copy of request for model, when set, asking to
+	// correct output, which failed with err.
+	reaskRequest(request interface{}, model string, output string, err error) (interface{}, error)
+
 	// Chat / Messages
 
 	chat(
@@ -30,10 +43,16 @@ type Instructor interface {
 		schema *Schema,
 	) (<-chan string, error)
 
+	// Request / response inspection
+
+	requestModel(request interface{}) string
+	finishReason(response interface{}) string
+	promptMessages(request interface{}) []promptMessage
+
 	// Usage counting
 
-	emptyResponseWithUsageSum(usage *UsageSum) interface{}
+	emptyResponseWithUsageSum(usage *Usage) interface{}
 	emptyResponseWithResponseUsage(response interface{}) interface{}
-	addUsageSumToResponse(response interface{}, usage *UsageSum) (interface{}, error)
-	countUsageFromResponse(response interface{}, usage *UsageSum) *UsageSum
+	addUsageSumToResponse(response interface{}, usage *Usage) (interface{}, error)
+	countUsageFromResponse(response interface{}, usage *Usage) *Usage
 }
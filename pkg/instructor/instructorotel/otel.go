@@ -0,0 +1,273 @@
+// Package instructorotel instruments instructor clients with OpenTelemetry.
+//
+// Every extraction produces a span with a child span per provider call, annotated
+// with the GenAI semantic-convention attributes, and records latency, token and
+// validation-failure metrics:
+//
+//	client := instructor.FromOpenAI(
+//		openai.NewClient(os.Getenv("OPENAI_API_KEY")),
+//		instructorotel.WithTelemetry(),
+//	)
+package instructorotel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/instructor-ai/instructor-go/pkg/instructor/instructorotel"
+
+// Attribute keys that have no GenAI semantic-convention equivalent.
+const (
+	ModeKey        = attribute.Key("instructor.mode")
+	SchemaKey      = attribute.Key("instructor.schema.name")
+	MaxRetriesKey  = attribute.Key("instructor.max_retries")
+	AttemptKey     = attribute.Key("instructor.attempt")
+	AttemptsKey    = attribute.Key("instructor.attempts")
+	RetriesKey     = attribute.Key("instructor.retries")
+	StreamKey      = attribute.Key("instructor.stream")
+	FailureTypeKey = attribute.Key("instructor.failure.type")
+	CostKey        = attribute.Key("instructor.cost.usd")
+	CacheHitKey    = attribute.Key("instructor.cache.hit")
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// Option configures the instrumentation.
+type Option func(*config)
+
+// WithTracerProvider sets the tracer provider. Defaults to the global provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithMeterProvider sets the meter provider. Defaults to the global provider.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = mp
+	}
+}
+
+// WithTelemetry returns client options that instrument every extraction.
+func WithTelemetry(opts ...Option) instructor.Options {
+	return instructor.WithHooks(New(opts...))
+}
+
+// Hooks implements instructor.Hooks on top of OpenTelemetry.
+type Hooks struct {
+	tracer trace.Tracer
+
+	operationDuration  metric.Float64Histogram
+	tokenUsage         metric.Int64Histogram
+	extractionDuration metric.Float64Histogram
+	attempts           metric.Int64Histogram
+	failures           metric.Int64Counter
+}
+
+var _ instructor.Hooks = &Hooks{}
+
+// New creates the instrumentation hooks.
+func New(opts ...Option) *Hooks {
+	c := config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	meter := c.meterProvider.Meter(instrumentationName)
+
+	h := &Hooks{
+		tracer: c.tracerProvider.Tracer(instrumentationName),
+	}
+
+	var err error
+	h.operationDuration, err = meter.Float64Histogram(
+		"gen_ai.client.operation.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of a single provider call."),
+	)
+	otel.Handle(err)
+	h.tokenUsage, err = meter.Int64Histogram(
+		"gen_ai.client.token.usage",
+		metric.WithUnit("{token}"),
+		metric.WithDescription("Number of input and output tokens used per provider call."),
+	)
+	otel.Handle(err)
+	h.extractionDuration, err = meter.Float64Histogram(
+		"instructor.extraction.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of an extraction, including retries."),
+	)
+	otel.Handle(err)
+	h.attempts, err = meter.Int64Histogram(
+		"instructor.extraction.attempts",
+		metric.WithUnit("{attempt}"),
+		metric.WithDescription("Number of provider calls made per extraction."),
+	)
+	otel.Handle(err)
+	h.failures, err = meter.Int64Counter(
+		"instructor.validation.failures",
+		metric.WithUnit("{failure}"),
+		metric.WithDescription("Number of model outputs that could not be decoded or failed validation."),
+	)
+	otel.Handle(err)
+
+	return h
+}
+
+type startTimeKey struct{ attempt bool }
+
+func (h *Hooks) ExtractionStart(ctx context.Context, info instructor.ExtractionInfo) context.Context {
+	ctx, _ = h.tracer.Start(ctx, spanName("extract", info.Schema),
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(extractionAttributes(info)...),
+	)
+	return context.WithValue(ctx, startTimeKey{}, time.Now())
+}
+
+func (h *Hooks) AttemptStart(ctx context.Context, info instructor.ExtractionInfo, attempt int) context.Context {
+	attrs := append(commonAttributes(info), AttemptKey.Int(attempt))
+
+	ctx, _ = h.tracer.Start(ctx, spanName(semconv.GenAIOperationNameChat.Value.AsString(), info.Model),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return context.WithValue(ctx, startTimeKey{attempt: true}, time.Now())
+}
+
+func (h *Hooks) AttemptEnd(ctx context.Context, info instructor.ExtractionInfo, result instructor.AttemptResult) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+
+	attrs := commonAttributes(info)
+
+	span.SetAttributes(
+		semconv.GenAIUsageInputTokens(result.Usage.InputTokens),
+		semconv.GenAIUsageOutputTokens(result.Usage.OutputTokens),
+	)
+	if result.FinishReason != "" {
+		span.SetAttributes(semconv.GenAIResponseFinishReasons(result.FinishReason))
+	}
+
+	switch {
+	case result.Err != nil:
+		span.RecordError(result.Err)
+		span.SetStatus(codes.Error, result.Err.Error())
+		attrs = append(attrs, semconv.ErrorTypeKey.String(errorType(result.Err)))
+	case result.DecodeErr != nil:
+		span.AddEvent("decode_failed", trace.WithAttributes(attribute.String("error.message", result.DecodeErr.Error())))
+		h.failures.Add(ctx, 1, metric.WithAttributes(append(commonAttributes(info), FailureTypeKey.String("decode"))...))
+	case result.ValidationErr != nil:
+		span.AddEvent("validation_failed", trace.WithAttributes(attribute.String("error.message", result.ValidationErr.Error())))
+		h.failures.Add(ctx, 1, metric.WithAttributes(append(commonAttributes(info), FailureTypeKey.String("validation"))...))
+	}
+
+	if start, ok := ctx.Value(startTimeKey{attempt: true}).(time.Time); ok {
+		h.operationDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+	}
+
+	// streams only have usage when the provider reports it, e.g. with
+	// StreamOptions.IncludeUsage for OpenAI
+	if result.Err == nil && (!info.Stream || result.Usage.TotalTokens > 0) {
+		h.tokenUsage.Record(ctx, int64(result.Usage.InputTokens), metric.WithAttributes(append(commonAttributes(info), semconv.GenAITokenTypeInput)...))
+		h.tokenUsage.Record(ctx, int64(result.Usage.OutputTokens), metric.WithAttributes(append(commonAttributes(info), semconv.GenAITokenTypeOutput)...))
+	}
+}
+
+func (h *Hooks) ExtractionEnd(ctx context.Context, info instructor.ExtractionInfo, result instructor.ExtractionResult) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+
+	attrs := extractionAttributes(info)
+
+	span.SetAttributes(
+		semconv.GenAIUsageInputTokens(result.Usage.InputTokens),
+		semconv.GenAIUsageOutputTokens(result.Usage.OutputTokens),
+		AttemptsKey.Int(result.Attempts),
+		RetriesKey.Int(max(result.Attempts-1, 0)),
+		CostKey.Float64(result.Cost.Total),
+		CacheHitKey.Bool(result.CacheHit),
+	)
+
+	if result.Err != nil {
+		span.RecordError(result.Err)
+		span.SetStatus(codes.Error, result.Err.Error())
+		attrs = append(attrs, semconv.ErrorTypeKey.String(errorType(result.Err)))
+	}
+
+	if start, ok := ctx.Value(startTimeKey{}).(time.Time); ok {
+		h.extractionDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+	}
+	h.attempts.Record(ctx, int64(result.Attempts), metric.WithAttributes(attrs...))
+}
+
+func commonAttributes(info instructor.ExtractionInfo) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.GenAIOperationNameChat,
+		system(info.Provider),
+		ModeKey.String(info.Mode),
+	}
+	if info.Model != "" {
+		attrs = append(attrs, semconv.GenAIRequestModel(info.Model))
+	}
+	return attrs
+}
+
+func extractionAttributes(info instructor.ExtractionInfo) []attribute.KeyValue {
+	return append(commonAttributes(info),
+		semconv.GenAIOutputTypeJSON,
+		SchemaKey.String(info.Schema),
+		MaxRetriesKey.Int(info.MaxRetries),
+		StreamKey.Bool(info.Stream),
+	)
+}
+
+func system(provider instructor.Provider) attribute.KeyValue {
+	switch provider {
+	case instructor.ProviderOpenAI:
+		return semconv.GenAISystemOpenAI
+	case instructor.ProviderAnthropic:
+		return semconv.GenAISystemAnthropic
+	case instructor.ProviderGoogle:
+		return semconv.GenAISystemGemini
+	case instructor.ProviderCohere:
+		return semconv.GenAISystemCohere
+	default:
+		return semconv.GenAISystemKey.String(provider)
+	}
+}
+
+func spanName(operation, target string) string {
+	if target == "" {
+		return operation
+	}
+	return operation + " " + target
+}
+
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	default:
+		return fmt.Sprintf("%T", err)
+	}
+}
@@ -0,0 +1,311 @@
+package instructor
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Redaction controls which parts of prompts and model outputs are written to logs.
+type Redaction int
+
+const (
+	// RedactSensitiveFields replaces the values of response struct fields tagged
+	// `instructor:"sensitive"` in logged outputs.
+	RedactSensitiveFields Redaction = 1 << iota
+	// RedactMessages replaces the contents of prompt messages. Their roles and
+	// sizes are still logged.
+	RedactMessages
+	// RedactOutputs omits raw model outputs and extracted JSON from logs.
+	RedactOutputs
+
+	// RedactNone logs prompts and outputs verbatim.
+	RedactNone Redaction = 0
+	// RedactAll redacts messages and outputs.
+	RedactAll = RedactSensitiveFields | RedactMessages | RedactOutputs
+
+	DefaultRedaction = RedactSensitiveFields
+)
+
+const redacted = "[REDACTED]"
+
+// SensitiveTag is the struct tag that marks a response field as sensitive:
+//
+//	SSN string `json:"ssn" instructor:"sensitive"`
+const SensitiveTag = "instructor"
+
+// WithLogger logs every extraction made by the client to logger.
+//
+// Extraction and attempt progress, prompt sizes, raw outputs and extracted JSON
+// are logged at debug level, decoding and validation failures at warn level and
+// provider errors at error level.
+func WithLogger(logger *slog.Logger) Options {
+	return Options{logger: logger}
+}
+
+// WithRedaction configures what WithLogger omits from logs. Defaults to
+// DefaultRedaction. Sensitive fields are redacted along with redaction unless
+// it is RedactNone.
+func WithRedaction(redaction Redaction) Options {
+	if redaction != RedactNone {
+		redaction |= RedactSensitiveFields
+	}
+	return Options{redaction: toPtr(redaction)}
+}
+
+type promptMessage struct {
+	Role    string
+	Content string
+}
+
+type logHooks struct {
+	logger    *slog.Logger
+	redaction Redaction
+}
+
+func (h *logHooks) ExtractionStart(ctx context.Context, info ExtractionInfo) context.Context {
+	if !h.logger.Enabled(ctx, slog.LevelDebug) {
+		return ctx
+	}
+
+	messages := info.client.promptMessages(info.request)
+
+	size := 0
+	logged := make([]any, 0, len(messages))
+	for idx, m := range messages {
+		size += len(m.Content)
+
+		content := m.Content
+		if h.redaction&RedactMessages != 0 {
+			content = redacted
+		}
+		logged = append(logged, slog.Group(
+			"message_"+strconv.Itoa(idx),
+			slog.String("role", m.Role),
+			slog.Int("size", len(m.Content)),
+			slog.String("content", content),
+		))
+	}
+
+	h.logger.DebugContext(ctx, "instructor: extraction started",
+		h.infoAttrs(info),
+		slog.Int("max_retries", info.MaxRetries),
+		slog.Bool("stream", info.Stream),
+		slog.Group("prompt",
+			slog.Int("messages", len(messages)),
+			slog.Int("size", size),
+		),
+		slog.Group("messages", logged...),
+	)
+
+	return ctx
+}
+
+func (h *logHooks) AttemptStart(ctx context.Context, info ExtractionInfo, attempt int) context.Context {
+	h.logger.DebugContext(ctx, "instructor: attempt started",
+		h.infoAttrs(info),
+		slog.Int("attempt", attempt),
+	)
+	return ctx
+}
+
+func (h *logHooks) AttemptEnd(ctx context.Context, info ExtractionInfo, result AttemptResult) {
+	attrs := []any{
+		h.infoAttrs(info),
+		slog.Int("attempt", result.Attempt),
+		slog.String("finish_reason", result.FinishReason),
+		usageAttr(result.Usage),
+	}
+
+	switch {
+	case result.Err != nil:
+		h.logger.ErrorContext(ctx, "instructor: provider call failed",
+			append(attrs, slog.Any("error", result.Err))...)
+	case result.DecodeErr != nil:
+		h.logger.WarnContext(ctx, "instructor: output could not be decoded",
+			append(attrs, slog.Any("error", result.DecodeErr), h.outputAttrs(info, result))...)
+	case result.ValidationErr != nil:
+		h.logger.WarnContext(ctx, "instructor: output failed validation",
+			append(attrs, slog.Any("error", result.ValidationErr), h.outputAttrs(info, result))...)
+	default:
+		h.logger.DebugContext(ctx, "instructor: attempt succeeded",
+			append(attrs, h.outputAttrs(info, result))...)
+	}
+}
+
+func (h *logHooks) ExtractionEnd(ctx context.Context, info ExtractionInfo, result ExtractionResult) {
+	attrs := []any{
+		h.infoAttrs(info),
+		slog.Int("attempts", result.Attempts),
+		slog.Bool("cache_hit", result.CacheHit),
+		usageAttr(result.Usage),
+	}
+
+	if result.Err != nil {
+		h.logger.ErrorContext(ctx, "instructor: extraction failed",
+			append(attrs, slog.Any("error", result.Err))...)
+		return
+	}
+
+	h.logger.DebugContext(ctx, "instructor: extraction finished", attrs...)
+}
+
+func (h *logHooks) infoAttrs(info ExtractionInfo) slog.Attr {
+	return slog.Group("extraction",
+		slog.String("provider", info.Provider),
+		slog.String("model", info.Model),
+		slog.String("mode", info.Mode),
+		slog.String("schema", info.Schema),
+	)
+}
+
+func (h *logHooks) outputAttrs(info ExtractionInfo, result AttemptResult) slog.Attr {
+	if h.redaction&RedactOutputs != 0 {
+		return slog.Group("output", slog.Int("size", len(result.Output)))
+	}
+
+	output, extracted := result.Output, result.JSON
+	if h.redaction&RedactSensitiveFields != 0 && hasSensitiveFields(info.responseType) {
+		extracted = redactJSON(extracted, info.responseType)
+		// The raw output cannot be redacted reliably, so only its size is kept.
+		output = redacted
+	}
+
+	return slog.Group("output",
+		slog.Int("size", len(result.Output)),
+		slog.String("raw", output),
+		slog.String("json", extracted),
+	)
+}
+
+func usageAttr(usage Usage) slog.Attr {
+	attrs := []any{
+		slog.Int("input_tokens", usage.InputTokens),
+		slog.Int("output_tokens", usage.OutputTokens),
+		slog.Int("total_tokens", usage.TotalTokens),
+	}
+	if usage.CachedInputTokens > 0 {
+		attrs = append(attrs, slog.Int("cached_input_tokens", usage.CachedInputTokens))
+	}
+	if usage.CacheCreationTokens > 0 {
+		attrs = append(attrs, slog.Int("cache_creation_tokens", usage.CacheCreationTokens))
+	}
+	if usage.ReasoningTokens > 0 {
+		attrs = append(attrs, slog.Int("reasoning_tokens", usage.ReasoningTokens))
+	}
+	return slog.Group("usage", attrs...)
+}
+
+func isSensitive(field reflect.StructField) bool {
+	for _, opt := range strings.Split(field.Tag.Get(SensitiveTag), ",") {
+		if opt == "sensitive" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSensitiveFields(t reflect.Type) bool {
+	return hasSensitiveFieldsSeen(t, map[reflect.Type]bool{})
+}
+
+func hasSensitiveFieldsSeen(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if t == nil || seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return hasSensitiveFieldsSeen(t.Elem(), seen)
+	case reflect.Struct:
+		for idx := 0; idx < t.NumField(); idx++ {
+			field := t.Field(idx)
+			if isSensitive(field) || hasSensitiveFieldsSeen(field.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redactJSON replaces the values of sensitive fields of t in text. Text that is
+// not valid JSON is redacted entirely.
+func redactJSON(text string, t reflect.Type) string {
+	var value any
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return redacted
+	}
+
+	b, err := json.Marshal(redactValue(value, t))
+	if err != nil {
+		return redacted
+	}
+	return string(b)
+}
+
+func redactValue(value any, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch v := value.(type) {
+	case []any:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return v
+		}
+		for idx := range v {
+			v[idx] = redactValue(v[idx], t.Elem())
+		}
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Map:
+			for k := range v {
+				v[k] = redactValue(v[k], t.Elem())
+			}
+		case reflect.Struct:
+			redactStruct(v, t)
+		}
+	}
+	return value
+}
+
+func redactStruct(v map[string]any, t reflect.Type) {
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			if field.Anonymous {
+				ft := field.Type
+				for ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					redactStruct(v, ft)
+					continue
+				}
+			}
+			name = field.Name
+		}
+
+		fieldValue, ok := v[name]
+		if !ok {
+			continue
+		}
+		if isSensitive(field) {
+			v[name] = redacted
+			continue
+		}
+		v[name] = redactValue(fieldValue, field.Type)
+	}
+}
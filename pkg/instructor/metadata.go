@@ -0,0 +1,156 @@
+package instructor
+
+import (
+	"context"
+	"sync"
+)
+
+// Metadata describes an extraction beyond what fits into the native provider
+// response. It is filled in for successful and failed extractions alike.
+type Metadata struct {
+	Provider Provider
+	Model    string
+	Attempts int
+	Usage    Usage
+	Cost     Cost
+	// CacheHit is set when the extraction was served from the cache given to WithCache.
+	CacheHit bool
+	// Coalesced is set when the extraction waited for an identical one given
+	// WithCoalescing, instead of calling the provider.
+	Coalesced bool
+	// Samples is the number of samples merged by a self-consistent extraction
+	// given WithSelfConsistency, and Agreement their agreement per JSON path.
+	Samples   int
+	Agreement map[string]float64
+	// Confidence is the confidence per JSON path of the response, given
+	// WithConfidence.
+	Confidence map[string]float64
+	// Reasoning is the reasoning of a reasoning model, given WithReasoning.
+	Reasoning string
+}
+
+type metadataKey struct{}
+
+// WithMetadata returns a context that records the Metadata of the next
+// extraction made with it into md:
+//
+//	var md instructor.Metadata
+//	resp, err := client.CreateChatCompletion(instructor.WithMetadata(ctx, &md), request, &person)
+//	fmt.Println(md.Cost.Total)
+func WithMetadata(ctx context.Context, md *Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+func metadataFromContext(ctx context.Context) *Metadata {
+	md, _ := ctx.Value(metadataKey{}).(*Metadata)
+	return md
+}
+
+// CostTracker sums the usage and cost of every provider call made with a
+// context it is attached to. It is safe for concurrent use.
+type CostTracker struct {
+	mu      sync.Mutex
+	calls   int
+	usage   Usage
+	cost    Cost
+	byModel map[string]*ModelSpend
+}
+
+// ModelSpend is the spend on a single provider model.
+type ModelSpend struct {
+	Provider Provider
+	Model    string
+	Calls    int
+	Usage    Usage
+	Cost     Cost
+}
+
+func NewCostTracker() *CostTracker {
+	return &CostTracker{
+		byModel: map[string]*ModelSpend{},
+	}
+}
+
+type costTrackersKey struct{}
+
+// WithCostTracker returns a context that records every provider call made with
+// it into tracker. Trackers nest: calls are also recorded into the trackers of
+// parent contexts, so a tracker per feature can coexist with a global one.
+func WithCostTracker(ctx context.Context, tracker *CostTracker) context.Context {
+	parents := costTrackersFromContext(ctx)
+	trackers := append(parents[:len(parents):len(parents)], tracker)
+	return context.WithValue(ctx, costTrackersKey{}, trackers)
+}
+
+// CostTrackerFromContext returns the innermost tracker attached to ctx, if any.
+func CostTrackerFromContext(ctx context.Context) *CostTracker {
+	trackers := costTrackersFromContext(ctx)
+	if len(trackers) == 0 {
+		return nil
+	}
+	return trackers[len(trackers)-1]
+}
+
+func costTrackersFromContext(ctx context.Context) []*CostTracker {
+	trackers, _ := ctx.Value(costTrackersKey{}).([]*CostTracker)
+	return trackers
+}
+
+// Add records a provider call.
+func (t *CostTracker) Add(provider Provider, model string, usage Usage, cost Cost) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.calls++
+	t.usage.add(&usage)
+	t.cost.add(cost)
+
+	key := provider + "/" + model
+	spend, ok := t.byModel[key]
+	if !ok {
+		spend = &ModelSpend{Provider: provider, Model: model}
+		t.byModel[key] = spend
+	}
+	spend.Calls++
+	spend.Usage.add(&usage)
+	spend.Cost.add(cost)
+}
+
+// Calls returns the number of provider calls recorded.
+func (t *CostTracker) Calls() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.calls
+}
+
+// Usage returns the summed usage of every recorded call.
+func (t *CostTracker) Usage() Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.usage
+}
+
+// Total returns the summed cost of every recorded call.
+func (t *CostTracker) Total() Cost {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cost
+}
+
+// ByModel returns the spend per provider model, keyed by "provider/model".
+func (t *CostTracker) ByModel() map[string]ModelSpend {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	spends := make(map[string]ModelSpend, len(t.byModel))
+	for key, spend := range t.byModel {
+		spends[key] = *spend
+	}
+	return spends
+}
+
+func recordCost(ctx context.Context, provider Provider, model string, usage Usage, cost Cost) {
+	for _, tracker := range costTrackersFromContext(ctx) {
+		tracker.Add(provider, model, usage, cost)
+	}
+}
@@ -0,0 +1,296 @@
+package instructor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+const ProviderMock Provider = "Mock"
+
+// Mock is an Instructor that never calls a provider. It is scripted with the
+// raw outputs to return per attempt and records every call it receives, to
+// unit-test extraction code, including its retry and validation behavior:
+//
+//	mock := instructor.NewMock(instructor.WithValidation()).
+//		Return(`not json`, `{"name": "Robby", "age": 22}`)
+//
+//	resp, err := mock.Chat(ctx, "Extract Robby is 22 years old.", &person)
+//
+//	calls := mock.Calls() // 2 attempts
+//
+// Options behave as they do for the provider clients.
+type Mock struct {
+	provider   Provider
+	mode       Mode
+	maxRetries int
+	validate   bool
+	opts       Options
+
+	mu      sync.Mutex
+	outputs []MockOutput
+	streams [][]string
+	calls   []MockCall
+}
+
+var _ Instructor = &Mock{}
+
+// MockOutput is the scripted outcome of a single attempt.
+type MockOutput struct {
+	// Text is the raw model output, which need not be valid JSON.
+	Text string
+	// Err fails the attempt as a provider error would.
+	Err          error
+	Usage        Usage
+	FinishReason string
+}
+
+// MockCall is a request received by a Mock.
+type MockCall struct {
+	Request interface{}
+	Schema  *Schema
+	Stream  bool
+}
+
+// MockResponse is the response of a Mock, standing in for a native provider response.
+type MockResponse struct {
+	Text         string
+	Usage        Usage
+	FinishReason string
+}
+
+func NewMock(opts ...Options) *Mock {
+	options := mergeOptions(opts...)
+
+	return &Mock{
+		provider:   ProviderMock,
+		mode:       *options.Mode,
+		maxRetries: *options.MaxRetries,
+		validate:   *options.validate,
+		opts:       options,
+	}
+}
+
+func (m *Mock) Provider() Provider {
+	return m.provider
+}
+
+func (m *Mock) Mode() Mode {
+	return m.mode
+}
+
+func (m *Mock) MaxRetries() int {
+	return m.maxRetries
+}
+
+func (m *Mock) Validate() bool {
+	return m.validate
+}
+
+func (m *Mock) options() Options {
+	return m.opts
+}
+
+// Return queues raw outputs, one per attempt.
+func (m *Mock) Return(texts ...string) *Mock {
+	outputs := make([]MockOutput, 0, len(texts))
+	for _, text := range texts {
+		outputs = append(outputs, MockOutput{Text: text, FinishReason: "stop"})
+	}
+	return m.ReturnOutputs(outputs...)
+}
+
+// ReturnOutputs queues outputs, one per attempt.
+func (m *Mock) ReturnOutputs(outputs ...MockOutput) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.outputs = append(m.outputs, outputs...)
+	return m
+}
+
+// ReturnStream queues the chunks of a streamed output. Streamed outputs wrap
+// their items in an object, as models are asked to:
+//
+//	mock.ReturnStream(`{"items": [{"name": "Robby"`, `}, {"name": "Lucy"}]}`)
+func (m *Mock) ReturnStream(chunks ...string) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.streams = append(m.streams, chunks)
+	return m
+}
+
+// Calls returns the calls received so far, one per attempt.
+func (m *Mock) Calls() []MockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]MockCall(nil), m.calls...)
+}
+
+// Remaining returns the number of queued outputs and streams not yet returned.
+func (m *Mock) Remaining() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.outputs) + len(m.streams)
+}
+
+// Chat extracts responseType from the scripted outputs. The request is only
+// recorded, so it can be of any type.
+func (m *Mock) Chat(ctx context.Context, request interface{}, responseType any) (*MockResponse, error) {
+	resp, err := chatHandler(m, ctx, request, responseType)
+	if resp == nil {
+		return nil, err
+	}
+	return resp.(*MockResponse), err
+}
+
+// ChatStream extracts a stream of responseType from the next scripted stream.
+func (m *Mock) ChatStream(ctx context.Context, request interface{}, responseType any) (<-chan any, error) {
+	return chatStreamHandler(m, ctx, request, responseType)
+}
+
+// Extract extracts responseType from the scripted outputs, recording request as is.
+func (m *Mock) Extract(ctx context.Context, request Request, responseType any) (*Response, error) {
+	return extract(m, ctx, request, responseType)
+}
+
+func (m *Mock) nativeRequest(request Request) (interface{}, error) {
+	return request, nil
+}
+
+func (m *Mock) chat(ctx context.Context, request interface{}, schema *Schema) (string, interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, MockCall{Request: request, Schema: schema})
+
+	if len(m.outputs) == 0 {
+		return "", nil, fmt.Errorf("mock: no output scripted for attempt %d", len(m.calls)-1)
+	}
+
+	output := m.outputs[0]
+	m.outputs = m.outputs[1:]
+
+	resp := &MockResponse{
+		Text:         output.Text,
+		Usage:        output.Usage,
+		FinishReason: output.FinishReason,
+	}
+
+	return output.Text, resp, output.Err
+}
+
+func (m *Mock) chatStream(ctx context.Context, request interface{}, schema *Schema) (<-chan string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, MockCall{Request: request, Schema: schema, Stream: true})
+
+	if len(m.streams) == 0 {
+		return nil, fmt.Errorf("mock: no stream scripted for call %d", len(m.calls)-1)
+	}
+
+	chunks := m.streams[0]
+	m.streams = m.streams[1:]
+
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		for _, chunk := range chunks {
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- chunk:
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+func (m *Mock) reaskRequest(request interface{}, model string, output string, err error) (interface{}, error) {
+	req, ok := request.(Request)
+	if !ok {
+		// other requests are only recorded, so there is nothing to add to
+		return request, nil
+	}
+	if model != "" {
+		req.Model = model
+	}
+	req.Messages = append(req.Messages[:len(req.Messages):len(req.Messages)], AssistantMessage(output), UserMessage(reaskPrompt(err)))
+	return req, nil
+}
+
+func (m *Mock) requestModel(request interface{}) string {
+	if req, ok := request.(Request); ok {
+		return req.Model
+	}
+	return ""
+}
+
+func (m *Mock) finishReason(response interface{}) string {
+	resp, ok := response.(*MockResponse)
+	if !ok || resp == nil {
+		return ""
+	}
+	return resp.FinishReason
+}
+
+func (m *Mock) promptMessages(request interface{}) []promptMessage {
+	switch req := request.(type) {
+	case string:
+		return []promptMessage{{Role: "user", Content: req}}
+	case Request:
+		var messages []promptMessage
+		if req.System != "" {
+			messages = append(messages, promptMessage{Role: RoleSystem, Content: req.System})
+		}
+		for _, m := range req.Messages {
+			messages = append(messages, promptMessage{Role: m.Role, Content: m.Content})
+		}
+		return messages
+	}
+	return nil
+}
+
+func (m *Mock) emptyResponseWithUsageSum(usage *Usage) interface{} {
+	resp := &MockResponse{}
+	resp.Usage.add(usage)
+	return resp
+}
+
+func (m *Mock) emptyResponseWithResponseUsage(response interface{}) interface{} {
+	resp, ok := response.(*MockResponse)
+	if !ok || resp == nil {
+		return nil
+	}
+
+	return &MockResponse{
+		Usage: resp.Usage,
+	}
+}
+
+func (m *Mock) addUsageSumToResponse(response interface{}, usage *Usage) (interface{}, error) {
+	resp, ok := response.(*MockResponse)
+	if !ok {
+		return response, fmt.Errorf("internal type error: expected *MockResponse, got %T", response)
+	}
+
+	resp.Usage.add(usage)
+
+	return response, nil
+}
+
+func (m *Mock) countUsageFromResponse(response interface{}, usage *Usage) *Usage {
+	resp, ok := response.(*MockResponse)
+	if !ok || resp == nil {
+		return usage
+	}
+
+	usage.add(&resp.Usage)
+
+	return usage
+}
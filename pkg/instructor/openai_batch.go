@@ -0,0 +1,259 @@
+package instructor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const DefaultBatchPollInterval = 30 * time.Second
+
+// OpenAIBatchRequest is an extraction request of an OpenAI batch.
+type OpenAIBatchRequest struct {
+	// CustomID identifies the request in the batch, and must be unique in it.
+	CustomID string
+	Request  openai.ChatCompletionRequest
+}
+
+// OpenAIBatchResult is the result of an extraction of an OpenAI batch. A
+// request that failed, returned an invalid response or was not processed
+// sets Err, and Response when the provider responded.
+type OpenAIBatchResult[T any] struct {
+	CustomID string
+	Value    *T
+	Response *openai.ChatCompletionResponse
+	Err      error
+}
+
+// NewOpenAIBatchFile returns the batch input file of requests extracting a T,
+// with the tools or JSON instructions of the mode of i added to every request.
+func NewOpenAIBatchFile[T any](i *InstructorOpenAI, requests []OpenAIBatchRequest) (openai.UploadBatchFileRequest, error) {
+	var file openai.UploadBatchFileRequest
+
+	schema, err := NewSchema(reflect.TypeOf(new(T)))
+	if err != nil {
+		return file, err
+	}
+
+	ids := make(map[string]bool, len(requests))
+	for _, r := range requests {
+		if r.CustomID == "" || ids[r.CustomID] {
+			return file, fmt.Errorf("batch request custom ID %q is empty or not unique", r.CustomID)
+		}
+		ids[r.CustomID] = true
+
+		req := r.Request
+		if req.Stream {
+			return file, fmt.Errorf("batch request %q: streaming is not supported in batches", r.CustomID)
+		}
+		if err := i.injectSchema(&req, schema); err != nil {
+			return file, err
+		}
+		file.AddChatCompletion(r.CustomID, req)
+	}
+	return file, nil
+}
+
+// SubmitOpenAIBatch uploads the batch input file of requests extracting a T
+// and creates a batch processing it within 24 hours:
+//
+//	batch, err := instructor.SubmitOpenAIBatch[Person](ctx, client, requests)
+//	batch, err = instructor.WaitOpenAIBatch(ctx, client, batch.ID, 0)
+//	results, err := instructor.OpenAIBatchResults[Person](ctx, client, batch, requests)
+//	err = instructor.RetryOpenAIBatch(ctx, client, requests, results)
+func SubmitOpenAIBatch[T any](ctx context.Context, i *InstructorOpenAI, requests []OpenAIBatchRequest) (openai.Batch, error) {
+	file, err := NewOpenAIBatchFile[T](i, requests)
+	if err != nil {
+		return openai.Batch{}, err
+	}
+
+	resp, err := i.Client.CreateBatchWithUploadFile(ctx, openai.CreateBatchWithUploadFileRequest{
+		Endpoint:               openai.BatchEndpointChatCompletions,
+		UploadBatchFileRequest: file,
+	})
+	return resp.Batch, err
+}
+
+// WaitOpenAIBatch polls the batch batchID every interval, DefaultBatchPollInterval
+// when zero, until it completed, failed, expired or was canceled. An expired
+// or canceled batch has the results of the requests processed in time.
+func WaitOpenAIBatch(ctx context.Context, i *InstructorOpenAI, batchID string, interval time.Duration) (openai.Batch, error) {
+	if interval <= 0 {
+		interval = DefaultBatchPollInterval
+	}
+
+	for {
+		resp, err := i.Client.RetrieveBatch(ctx, batchID)
+		if err != nil {
+			return resp.Batch, err
+		}
+
+		switch resp.Status {
+		case "completed", "expired", "cancelled":
+			return resp.Batch, nil
+		case "failed":
+			var msgs []string
+			if resp.Errors != nil {
+				for _, e := range resp.Errors.Data {
+					msgs = append(msgs, e.Message)
+				}
+			}
+			return resp.Batch, fmt.Errorf("batch %s failed: %s", batchID, strings.Join(msgs, "; "))
+		}
+
+		if err := sleep(ctx, interval, nil); err != nil {
+			return resp.Batch, err
+		}
+	}
+}
+
+// openAIBatchLine is a line of the output or error file of a batch.
+type openAIBatchLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// OpenAIBatchResults reads the output and error files of batch, decoding and
+// validating a T from every response. Results are in the order of requests.
+func OpenAIBatchResults[T any](ctx context.Context, i *InstructorOpenAI, batch openai.Batch, requests []OpenAIBatchRequest) ([]OpenAIBatchResult[T], error) {
+	schema, err := NewSchema(reflect.TypeOf(new(T)))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]OpenAIBatchResult[T], len(requests))
+	index := make(map[string]int, len(requests))
+	for k, r := range requests {
+		index[r.CustomID] = k
+		results[k] = OpenAIBatchResult[T]{
+			CustomID: r.CustomID,
+			Err:      fmt.Errorf("request %q has no result in batch %s (%s)", r.CustomID, batch.ID, batch.Status),
+		}
+	}
+
+	for _, fileID := range []*string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == nil || *fileID == "" {
+			continue
+		}
+
+		content, err := i.Client.GetFileContent(ctx, *fileID)
+		if err != nil {
+			return results, err
+		}
+
+		dec := json.NewDecoder(content)
+		for {
+			var line openAIBatchLine
+			err := dec.Decode(&line)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				content.Close()
+				return results, fmt.Errorf("reading batch file %s: %w", *fileID, err)
+			}
+
+			if k, ok := index[line.CustomID]; ok {
+				results[k] = openAIBatchResult[T](i, schema, line)
+			}
+		}
+		content.Close()
+	}
+
+	return results, nil
+}
+
+func openAIBatchResult[T any](i *InstructorOpenAI, schema *Schema, line openAIBatchLine) OpenAIBatchResult[T] {
+	result := OpenAIBatchResult[T]{CustomID: line.CustomID}
+
+	switch {
+	case line.Response == nil && line.Error != nil:
+		result.Err = fmt.Errorf("batch request %q failed: %s: %s", line.CustomID, line.Error.Code, line.Error.Message)
+		return result
+	case line.Response == nil:
+		result.Err = fmt.Errorf("batch request %q has no response", line.CustomID)
+		return result
+	case line.Response.StatusCode != 200:
+		var errResp openai.ErrorResponse
+		if json.Unmarshal(line.Response.Body, &errResp) == nil && errResp.Error != nil {
+			errResp.Error.HTTPStatusCode = line.Response.StatusCode
+			result.Err = errResp.Error
+		} else {
+			result.Err = fmt.Errorf("batch request %q failed with status %d", line.CustomID, line.Response.StatusCode)
+		}
+		return result
+	}
+
+	var resp openai.ChatCompletionResponse
+	if err := json.Unmarshal(line.Response.Body, &resp); err != nil {
+		result.Err = err
+		return result
+	}
+	result.Response = &resp
+
+	outputs := i.choiceOutputs(&resp, schema)
+	if len(outputs) == 0 {
+		result.Err = fmt.Errorf("batch request %q: received no output from model", line.CustomID)
+		return result
+	}
+
+	text := extractJSON(&outputs[0])
+	value := new(T)
+	if err := json.Unmarshal([]byte(text), value); err != nil {
+		result.Err = err
+		return result
+	}
+	if i.Validate() {
+		if err := validate.Struct(value); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+
+	result.Value = value
+	return result
+}
+
+// RetryOpenAIBatch extracts the failed results of a batch synchronously,
+// replacing them with the results of the retries. results are matched to
+// requests by CustomID.
+func RetryOpenAIBatch[T any](ctx context.Context, i *InstructorOpenAI, requests []OpenAIBatchRequest, results []OpenAIBatchResult[T]) error {
+	index := make(map[string]int, len(requests))
+	for k, r := range requests {
+		index[r.CustomID] = k
+	}
+	for _, r := range results {
+		if _, ok := index[r.CustomID]; r.Err != nil && !ok {
+			return fmt.Errorf("batch result %q has no request", r.CustomID)
+		}
+	}
+
+	for k := range results {
+		if results[k].Err == nil {
+			continue
+		}
+
+		value := new(T)
+		resp, err := i.CreateChatCompletion(ctx, requests[index[results[k].CustomID]].Request, value)
+		results[k] = OpenAIBatchResult[T]{CustomID: results[k].CustomID, Err: err}
+		if err == nil {
+			results[k].Value = value
+			results[k].Response = &resp
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/invopop/jsonschema"
 	openai "github.com/sashabaranov/go-openai"
@@ -37,6 +38,56 @@ func (i *InstructorOpenAI) CreateChatCompletion(
 	return response, nil
 }
 
+func (i *InstructorOpenAI) Extract(ctx context.Context, request Request, responseType any) (*Response, error) {
+	return extract(i, ctx, request, responseType)
+}
+
+func (i *InstructorOpenAI) nativeRequest(request Request) (interface{}, error) {
+	req := openai.ChatCompletionRequest{
+		Model: request.Model,
+	}
+	// reasoning models count their reasoning into max_completion_tokens, and
+	// only support the default temperature
+	if restrictsOpenAIParameters(request.Model) {
+		req.MaxCompletionTokens = request.MaxTokens
+	} else {
+		req.MaxTokens = request.MaxTokens
+		if request.Temperature != nil {
+			req.Temperature = *request.Temperature
+		}
+	}
+
+	if request.System != "" {
+		req.Messages = append(req.Messages, openai.ChatCompletionMessage{Role: openAIInstructionRole(request.Model), Content: request.System})
+	}
+
+	for _, m := range request.Messages {
+		msg := openai.ChatCompletionMessage{Role: m.Role}
+
+		if len(m.Images) == 0 {
+			msg.Content = m.Content
+			req.Messages = append(req.Messages, msg)
+			continue
+		}
+
+		if m.Content != "" {
+			msg.MultiContent = append(msg.MultiContent, openai.ChatMessagePart{Type: openai.ChatMessagePartTypeText, Text: m.Content})
+		}
+		for _, img := range m.Images {
+			if err := img.validate(); err != nil {
+				return nil, err
+			}
+			msg.MultiContent = append(msg.MultiContent, openai.ChatMessagePart{
+				Type:     openai.ChatMessagePartTypeImageURL,
+				ImageURL: &openai.ChatMessageImageURL{URL: img.dataURL()},
+			})
+		}
+		req.Messages = append(req.Messages, msg)
+	}
+
+	return req, nil
+}
+
 func (i *InstructorOpenAI) chat(ctx context.Context, request interface{}, schema *Schema) (string, interface{}, error) {
 
 	req, ok := request.(openai.ChatCompletionRequest)
@@ -48,20 +99,97 @@ func (i *InstructorOpenAI) chat(ctx context.Context, request interface{}, schema
 		return "", nil, errors.New("streaming is not supported by this method; use CreateChatCompletionStream instead")
 	}
 
+	// tool call arguments and reasoning models have no log probabilities
+	toolCall := i.Mode() == ModeToolCall || i.Mode() == ModeToolCallStrict
+	if confidence := i.options().confidence; confidence != nil && *confidence && !toolCall && !restrictsOpenAIParameters(req.Model) {
+		req.LogProbs = true
+	}
+
+	var text string
+	var resp *openai.ChatCompletionResponse
+	var err error
 	switch i.Mode() {
 	case ModeToolCall:
-		return i.chatToolCall(ctx, &req, schema, false)
+		text, resp, err = i.chatToolCall(ctx, &req, schema, false)
 	case ModeToolCallStrict:
-		return i.chatToolCall(ctx, &req, schema, true)
+		text, resp, err = i.chatToolCall(ctx, &req, schema, true)
 	case ModeJSON:
-		return i.chatJSON(ctx, &req, schema, false)
+		text, resp, err = i.chatJSON(ctx, &req, schema, false)
 	case ModeJSONStrict:
-		return i.chatJSON(ctx, &req, schema, true)
+		text, resp, err = i.chatJSON(ctx, &req, schema, true)
 	case ModeJSONSchema:
-		return i.chatJSONSchema(ctx, &req, schema)
+		text, resp, err = i.chatJSONSchema(ctx, &req, schema)
 	default:
 		return "", nil, fmt.Errorf("mode '%s' is not supported for %s", i.Mode(), i.Provider())
 	}
+
+	if sink := sampleSinkFromContext(ctx); sink != nil && err == nil {
+		sink.record(resp, i.choiceOutputs(resp, schema))
+	}
+
+	return text, resp, err
+}
+
+func (i *InstructorOpenAI) sampleRequest(request interface{}, n int) (interface{}, bool, error) {
+	req, ok := request.(openai.ChatCompletionRequest)
+	if !ok {
+		return nil, false, fmt.Errorf("invalid request type for %s client", i.Provider())
+	}
+	// reasoning models only return a single choice
+	if restrictsOpenAIParameters(req.Model) {
+		return nil, false, nil
+	}
+	req.N = n
+	return req, true, nil
+}
+
+func (i *InstructorOpenAI) confidence(response interface{}, schema *Schema) map[string]float64 {
+	resp, ok := response.(*openai.ChatCompletionResponse)
+	if !ok || resp == nil || len(resp.Choices) == 0 || resp.Choices[0].LogProbs == nil {
+		return nil
+	}
+
+	var tokens []tokenLogprob
+	for _, token := range resp.Choices[0].LogProbs.Content {
+		size := len(token.Token)
+		if token.Bytes != nil {
+			size = len(token.Bytes)
+		}
+		tokens = append(tokens, tokenLogprob{size: size, logprob: token.LogProb})
+	}
+
+	confidence := fieldConfidence(resp.Choices[0].Message.Content, tokens)
+	if i.Mode() != ModeJSONStrict {
+		return confidence
+	}
+
+	// strict JSON wraps the response into an object keyed by the schema name
+	wrapper := "$." + schema.NameFromRef()
+	unwrapped := make(map[string]float64, len(confidence))
+	for path, c := range confidence {
+		if rest, ok := strings.CutPrefix(path, wrapper); ok && (rest == "" || rest[0] == '.' || rest[0] == '[') {
+			unwrapped["$"+rest] = c
+		}
+	}
+	return unwrapped
+}
+
+// choiceOutputs returns the output of every choice of resp.
+func (i *InstructorOpenAI) choiceOutputs(resp *openai.ChatCompletionResponse, schema *Schema) []string {
+	outputs := make([]string, 0, len(resp.Choices))
+	for _, choice := range resp.Choices {
+		switch i.Mode() {
+		case ModeToolCall, ModeToolCallStrict:
+			if text, err := toolCallsJSON(choice.Message.ToolCalls); err == nil {
+				outputs = append(outputs, text)
+			}
+		case ModeJSONStrict:
+			outputs = append(outputs, unwrapJSON(choice.Message.Content, schema.NameFromRef()))
+		default:
+			outputs = append(outputs, choice.Message.Content)
+		}
+	}
+	return outputs
 }
 
 func (i *InstructorOpenAI) chatToolCall(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema, strict bool) (string, *openai.ChatCompletionResponse, error) {
@@ -82,14 +210,25 @@ func (i *InstructorOpenAI) chatToolCall(ctx context.Context, request *openai.Cha
 		}
 	}
 
+	text, err := toolCallsJSON(toolCalls)
+	if err != nil {
+		return "", nilOpenaiRespWithUsage(&resp), err
+	}
+
+	return text, &resp, nil
+}
+
+// toolCallsJSON returns the arguments of a single tool call, or a JSON array
+// of the arguments of every tool call.
+func toolCallsJSON(toolCalls []openai.ToolCall) (string, error) {
 	numTools := len(toolCalls)
 
 	if numTools < 1 {
-		return "", nilOpenaiRespWithUsage(&resp), errors.New("received no tool calls from model, expected at least 1")
+		return "", errors.New("received no tool calls from model, expected at least 1")
 	}
 
 	if numTools == 1 {
-		return toolCalls[0].Function.Arguments, &resp, nil
+		return toolCalls[0].Function.Arguments, nil
 	}
 
 	// numTools >= 1
@@ -98,26 +237,45 @@ func (i *InstructorOpenAI) chatToolCall(ctx context.Context, request *openai.Cha
 
 	for i, toolCall := range toolCalls {
 		var jsonObj map[string]interface{}
-		err = json.Unmarshal([]byte(toolCall.Function.Arguments), &jsonObj)
+		err := json.Unmarshal([]byte(toolCall.Function.Arguments), &jsonObj)
 		if err != nil {
-			return "", nilOpenaiRespWithUsage(&resp), err
+			return "", err
 		}
 		jsonArray[i] = jsonObj
 	}
 
 	resultJSON, err := json.Marshal(jsonArray)
 	if err != nil {
-		return "", nilOpenaiRespWithUsage(&resp), err
+		return "", err
 	}
 
-	return string(resultJSON), &resp, nil
+	return string(resultJSON), nil
 }
 
 func (i *InstructorOpenAI) chatJSON(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema, strict bool) (string, *openai.ChatCompletionResponse, error) {
 
+	injectOpenAIJSON(request, schema, strict)
+
+	resp, err := i.Client.CreateChatCompletion(ctx, *request)
+	if err != nil {
+		return "", nil, err
+	}
+
+	text := resp.Choices[0].Message.Content
+
+	if strict {
+		text = unwrapJSON(text, schema.NameFromRef())
+	}
+
+	return text, &resp, nil
+}
+
+// injectOpenAIJSON asks for a JSON response to request, following schema when strict.
+func injectOpenAIJSON(request *openai.ChatCompletionRequest, schema *Schema, strict bool) {
+
 	structName := schema.NameFromRef()
 
-	request.Messages = prepend(request.Messages, *createJSONMessage(schema))
+	request.Messages = prepend(request.Messages, *createJSONMessage(schema, request.Model))
 
 	if strict {
 		schemaWrapper := ResponseFormatSchemaWrapper{
@@ -147,6 +305,31 @@ func (i *InstructorOpenAI) chatJSON(ctx context.Context, request *openai.ChatCom
 			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
 		}
 	}
+}
+
+// injectSchema adds the instructions of the mode of i for schema to request,
+// as the chat methods of the mode do.
+func (i *InstructorOpenAI) injectSchema(request *openai.ChatCompletionRequest, schema *Schema) error {
+	switch i.Mode() {
+	case ModeToolCall:
+		request.Tools = createOpenAITools(schema, false)
+	case ModeToolCallStrict:
+		request.Tools = createOpenAITools(schema, true)
+	case ModeJSON:
+		injectOpenAIJSON(request, schema, false)
+	case ModeJSONStrict:
+		injectOpenAIJSON(request, schema, true)
+	case ModeJSONSchema:
+		request.Messages = prepend(request.Messages, *createJSONMessage(schema, request.Model))
+	default:
+		return fmt.Errorf("mode '%s' is not supported for %s", i.Mode(), i.Provider())
+	}
+	return nil
+}
+
+func (i *InstructorOpenAI) chatJSONSchema(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema) (string, *openai.ChatCompletionResponse, error) {
+
+	request.Messages = prepend(request.Messages, *createJSONMessage(schema, request.Model))
 
 	resp, err := i.Client.CreateChatCompletion(ctx, *request)
 	if err != nil {
@@ -155,39 +338,103 @@ func (i *InstructorOpenAI) chatJSON(ctx context.Context, request *openai.ChatCom
 
 	text := resp.Choices[0].Message.Content
 
-	if strict {
-		resMap := make(map[string]any)
-		_ = json.Unmarshal([]byte(text), &resMap)
+	return text, &resp, nil
+}
 
-		cleanedText, _ := json.Marshal(resMap[structName])
-		text = string(cleanedText)
+func (i *InstructorOpenAI) requestModel(request interface{}) string {
+	req, ok := request.(openai.ChatCompletionRequest)
+	if !ok {
+		return ""
 	}
+	return req.Model
+}
 
-	return text, &resp, nil
+func (i *InstructorOpenAI) reaskRequest(request interface{}, model string, output string, err error) (interface{}, error) {
+	req, ok := request.(openai.ChatCompletionRequest)
+	if !ok {
+		return nil, fmt.Errorf("invalid request type for %s client", i.Provider())
+	}
+	if model != "" {
+		req.Model = model
+	}
+	req.Messages = append(req.Messages[:len(req.Messages):len(req.Messages)],
+		openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: output},
+		openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: reaskPrompt(err)},
+	)
+	return req, nil
 }
 
-func (i *InstructorOpenAI) chatJSONSchema(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema) (string, *openai.ChatCompletionResponse, error) {
+func (i *InstructorOpenAI) continueChat(ctx context.Context, request interface{}, schema *Schema, output string) (string, interface{}, bool, error) {
+	req, ok := request.(openai.ChatCompletionRequest)
+	// JSON modes force a complete JSON response, and tool calls cannot be continued
+	if !ok || i.Mode() != ModeJSONSchema {
+		return "", nil, false, nil
+	}
 
-	request.Messages = prepend(request.Messages, *createJSONMessage(schema))
+	req.Messages = append(req.Messages[:len(req.Messages):len(req.Messages)],
+		openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: output},
+		openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: continuePrompt},
+	)
+	req.Messages = prepend(req.Messages, *createJSONMessage(schema, req.Model))
+	// the log probabilities would only cover the continuation, so a continued
+	// output reports no confidence
+	req.LogProbs = false
 
-	resp, err := i.Client.CreateChatCompletion(ctx, *request)
+	resp, err := i.Client.CreateChatCompletion(ctx, req)
 	if err != nil {
-		return "", nil, err
+		return "", nil, true, err
+	}
+	if len(resp.Choices) == 0 {
+		return "", nilOpenaiRespWithUsage(&resp), true, errors.New("received no choices from model")
 	}
+	return output + resp.Choices[0].Message.Content, &resp, true, nil
+}
 
-	text := resp.Choices[0].Message.Content
+func (i *InstructorOpenAI) raiseLimit(request interface{}, used int, ceiling int) (interface{}, bool) {
+	req, ok := request.(openai.ChatCompletionRequest)
+	if !ok {
+		return nil, false
+	}
 
-	return text, &resp, nil
+	// reasoning models count their reasoning into max_completion_tokens
+	limit := &req.MaxTokens
+	if req.MaxCompletionTokens > 0 || restrictsOpenAIParameters(req.Model) {
+		limit = &req.MaxCompletionTokens
+	}
+	raised, ok := raisedLimit(*limit, used, ceiling)
+	*limit = raised
+	return req, ok
 }
 
-func (i *InstructorOpenAI) emptyResponseWithUsageSum(usage *UsageSum) interface{} {
-	return &openai.ChatCompletionResponse{
-		Usage: openai.Usage{
-			PromptTokens:     usage.InputTokens,
-			CompletionTokens: usage.OutputTokens,
-			TotalTokens:      usage.TotalTokens,
-		},
+func (i *InstructorOpenAI) finishReason(response interface{}) string {
+	resp, ok := response.(*openai.ChatCompletionResponse)
+	if !ok || resp == nil || len(resp.Choices) == 0 {
+		return ""
 	}
+	return string(resp.Choices[0].FinishReason)
+}
+
+func (i *InstructorOpenAI) promptMessages(request interface{}) []promptMessage {
+	req, ok := request.(openai.ChatCompletionRequest)
+	if !ok {
+		return nil
+	}
+
+	messages := make([]promptMessage, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		content := m.Content
+		for _, part := range m.MultiContent {
+			content += part.Text
+		}
+		messages = append(messages, promptMessage{Role: m.Role, Content: content})
+	}
+	return messages
+}
+
+func (i *InstructorOpenAI) emptyResponseWithUsageSum(usage *Usage) interface{} {
+	resp := &openai.ChatCompletionResponse{}
+	addOpenAIUsage(&resp.Usage, usage)
+	return resp
 }
 
 func (i *InstructorOpenAI) emptyResponseWithResponseUsage(response interface{}) interface{} {
@@ -201,33 +448,58 @@ func (i *InstructorOpenAI) emptyResponseWithResponseUsage(response interface{})
 	}
 }
 
-func (i *InstructorOpenAI) addUsageSumToResponse(response interface{}, usage *UsageSum) (interface{}, error) {
+func (i *InstructorOpenAI) addUsageSumToResponse(response interface{}, usage *Usage) (interface{}, error) {
 	resp, ok := response.(*openai.ChatCompletionResponse)
 	if !ok {
 		return response, fmt.Errorf("internal type error: expected *openai.ChatCompletionResponse, got %T", response)
 	}
 
-	resp.Usage.PromptTokens += usage.InputTokens
-	resp.Usage.CompletionTokens += usage.OutputTokens
-	resp.Usage.TotalTokens += usage.TotalTokens
+	addOpenAIUsage(&resp.Usage, usage)
 
 	return response, nil
 }
 
-func (i *InstructorOpenAI) countUsageFromResponse(response interface{}, usage *UsageSum) *UsageSum {
+func (i *InstructorOpenAI) countUsageFromResponse(response interface{}, usage *Usage) *Usage {
 	resp, ok := response.(*openai.ChatCompletionResponse)
-	if !ok {
+	if !ok || resp == nil {
 		return usage
 	}
 
 	usage.InputTokens += resp.Usage.PromptTokens
 	usage.OutputTokens += resp.Usage.CompletionTokens
 	usage.TotalTokens += resp.Usage.TotalTokens
+	if details := resp.Usage.PromptTokensDetails; details != nil {
+		usage.CachedInputTokens += details.CachedTokens
+	}
+	if details := resp.Usage.CompletionTokensDetails; details != nil {
+		usage.ReasoningTokens += details.ReasoningTokens
+	}
 
 	return usage
 }
 
-func createJSONMessage(schema *Schema) *openai.ChatCompletionMessage {
+// addOpenAIUsage adds usage to native. OpenAI already counts cached tokens as
+// prompt tokens and reasoning tokens as completion tokens.
+func addOpenAIUsage(native *openai.Usage, usage *Usage) {
+	native.PromptTokens += usage.InputTokens
+	native.CompletionTokens += usage.OutputTokens
+	native.TotalTokens += usage.TotalTokens
+
+	if usage.CachedInputTokens > 0 {
+		if native.PromptTokensDetails == nil {
+			native.PromptTokensDetails = &openai.PromptTokensDetails{}
+		}
+		native.PromptTokensDetails.CachedTokens += usage.CachedInputTokens
+	}
+	if usage.ReasoningTokens > 0 {
+		if native.CompletionTokensDetails == nil {
+			native.CompletionTokensDetails = &openai.CompletionTokensDetails{}
+		}
+		native.CompletionTokensDetails.ReasoningTokens += usage.ReasoningTokens
+	}
+}
+
+func createJSONMessage(schema *Schema, model string) *openai.ChatCompletionMessage {
 	message := fmt.Sprintf(`
 Please respond with JSON in the following JSON schema:
 
@@ -237,7 +509,7 @@ Make sure to return an instance of the JSON, not the schema itself
 `, schema.String)
 
 	msg := &openai.ChatCompletionMessage{
-		Role:    openai.ChatMessageRoleSystem,
+		Role:    openAIInstructionRole(model),
 		Content: message,
 	}
 
@@ -267,7 +539,13 @@ func nilOpenaiRespWithUsage(resp *openai.ChatCompletionResponse) *openai.ChatCom
 		return nil
 	}
 
+	// the finish reason tells a truncated output apart
+	var choices []openai.ChatCompletionChoice
+	if len(resp.Choices) > 0 {
+		choices = []openai.ChatCompletionChoice{{FinishReason: resp.Choices[0].FinishReason}}
+	}
 	return &openai.ChatCompletionResponse{
-		Usage: resp.Usage,
+		Choices: choices,
+		Usage:   resp.Usage,
 	}
 }
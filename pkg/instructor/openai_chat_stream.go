@@ -54,18 +54,18 @@ func (i *InstructorOpenAI) chatToolCallStream(ctx context.Context, request *open
 }
 
 func (i *InstructorOpenAI) chatJSONStream(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema) (<-chan string, error) {
-	request.Messages = prepend(request.Messages, *createJSONMessageStream(schema))
+	request.Messages = prepend(request.Messages, *createJSONMessageStream(schema, request.Model))
 	// Set JSON mode
 	request.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
 	return i.createStream(ctx, request)
 }
 
 func (i *InstructorOpenAI) chatJSONSchemaStream(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema) (<-chan string, error) {
-	request.Messages = prepend(request.Messages, *createJSONMessageStream(schema))
+	request.Messages = prepend(request.Messages, *createJSONMessageStream(schema, request.Model))
 	return i.createStream(ctx, request)
 }
 
-func createJSONMessageStream(schema *Schema) *openai.ChatCompletionMessage {
+func createJSONMessageStream(schema *Schema, model string) *openai.ChatCompletionMessage {
 	message := fmt.Sprintf(`
 Please respond with a JSON array where the elements following JSON schema:
 
@@ -75,7 +75,7 @@ Make sure to return an array with the elements an instance of the JSON, not the
 `, schema.String)
 
 	msg := &openai.ChatCompletionMessage{
-		Role:    openai.ChatMessageRoleSystem,
+		Role:    openAIInstructionRole(model),
 		Content: message,
 	}
 
@@ -101,8 +101,19 @@ func (i *InstructorOpenAI) createStream(ctx context.Context, request *openai.Cha
 			if err != nil {
 				return
 			}
-			text := response.Choices[0].Delta.Content
-			ch <- text
+			if response.Usage != nil {
+				reportStreamUsage(ctx, i.countUsageFromResponse(&openai.ChatCompletionResponse{Usage: *response.Usage}, &Usage{}))
+			}
+			// the final chunk of a stream with usage has no choices
+			if len(response.Choices) == 0 {
+				continue
+			}
+
+			delta := response.Choices[0].Delta
+			ch <- delta.Content
+			for _, toolCall := range delta.ToolCalls {
+				ch <- toolCall.Function.Arguments
+			}
 		}
 	}()
 	return ch, nil
@@ -11,6 +11,7 @@ type InstructorOpenAI struct {
 	mode       Mode
 	maxRetries int
 	validate   bool
+	opts       Options
 }
 
 var _ Instructor = &InstructorOpenAI{}
@@ -26,6 +27,7 @@ func FromOpenAI(client *openai.Client, opts ...Options) *InstructorOpenAI {
 		mode:       *options.Mode,
 		maxRetries: *options.MaxRetries,
 		validate:   *options.validate,
+		opts:       options,
 	}
 	return i
 }
@@ -42,3 +44,7 @@ func (i *InstructorOpenAI) MaxRetries() int {
 func (i *InstructorOpenAI) Validate() bool {
 	return i.validate
 }
+
+func (i *InstructorOpenAI) options() Options {
+	return i.opts
+}
@@ -1,21 +1,40 @@
 package instructor
 
+import (
+	"log/slog"
+)
+
 const (
 	DefaultMaxRetries = 3
 	DefaultValidator  = false
 )
 
 type Options struct {
-	Mode       *Mode
-	MaxRetries *int
-	validate   *bool
+	Mode        *Mode
+	MaxRetries  *int
+	validate    *bool
+	hooks       hookList
+	logger      *slog.Logger
+	redaction   *Redaction
+	prices      PriceTable
+	cache       Cache
+	escalation  []Escalation
+	breaker     *CircuitBreaker
+	flights     *flightGroup
+	hedge       *Hedge
+	consistency *SelfConsistency
+	confidence  *bool
+	reasoning   *bool
+	truncation  *Truncation
 	// Provider specific options:
+	promptCaching *bool
 }
 
 var defaultOptions = Options{
 	Mode:       toPtr(ModeDefault),
 	MaxRetries: toPtr(DefaultMaxRetries),
 	validate:   toPtr(DefaultValidator),
+	redaction:  toPtr(DefaultRedaction),
 }
 
 func WithMode(mode Mode) Options {
@@ -30,6 +49,23 @@ func WithValidation() Options {
 	return Options{validate: toPtr(true)}
 }
 
+// WithPromptCaching marks the tool definitions and the JSON schema system
+// block added by Anthropic clients with cache_control, so that extractions
+// with the same schema read them from the prompt cache instead of paying for
+// them in full. Cache reads and writes are reported in Usage as
+// CachedInputTokens and CacheCreationTokens. The provider only caches prompts
+// above a minimum length, and the system block is only a cache hit when the
+// system prompt before it is the same.
+func WithPromptCaching() Options {
+	return Options{promptCaching: toPtr(true)}
+}
+
+// WithHooks registers hooks that observe every extraction made by the client.
+// Hooks from multiple options are called in the order they were given.
+func WithHooks(hooks ...Hooks) Options {
+	return Options{hooks: hooks}
+}
+
 func mergeOption(old, new Options) Options {
 	if new.Mode != nil {
 		old.Mode = new.Mode
@@ -40,6 +76,48 @@ func mergeOption(old, new Options) Options {
 	if new.validate != nil {
 		old.validate = new.validate
 	}
+	if len(new.hooks) > 0 {
+		old.hooks = append(old.hooks[:len(old.hooks):len(old.hooks)], new.hooks...)
+	}
+	if new.logger != nil {
+		old.logger = new.logger
+	}
+	if new.redaction != nil {
+		old.redaction = new.redaction
+	}
+	if new.cache != nil {
+		old.cache = new.cache
+	}
+	if new.promptCaching != nil {
+		old.promptCaching = new.promptCaching
+	}
+	if new.truncation != nil {
+		old.truncation = new.truncation
+	}
+	if new.reasoning != nil {
+		old.reasoning = new.reasoning
+	}
+	if new.confidence != nil {
+		old.confidence = new.confidence
+	}
+	if new.consistency != nil {
+		old.consistency = new.consistency
+	}
+	if new.hedge != nil {
+		old.hedge = new.hedge
+	}
+	if new.flights != nil {
+		old.flights = new.flights
+	}
+	if new.breaker != nil {
+		old.breaker = new.breaker
+	}
+	if len(new.escalation) > 0 {
+		old.escalation = new.escalation
+	}
+	if len(new.prices) > 0 {
+		old.prices = mergePrices(old.prices, new.prices)
+	}
 
 	return old
 }
@@ -53,3 +131,27 @@ func mergeOptions(opts ...Options) Options {
 
 	return options
 }
+
+// allHooks returns the registered hooks followed by the ones implied by other options.
+func (o Options) allHooks() hookList {
+	hooks := o.hooks
+	if o.logger != nil {
+		hooks = append(hooks[:len(hooks):len(hooks)], &logHooks{logger: o.logger, redaction: *o.redaction})
+	}
+	return hooks
+}
+
+func mergePrices(old, new PriceTable) PriceTable {
+	merged := make(PriceTable, len(old)+len(new))
+	for _, table := range []PriceTable{old, new} {
+		for provider, models := range table {
+			if merged[provider] == nil {
+				merged[provider] = map[string]ModelPrice{}
+			}
+			for model, price := range models {
+				merged[provider][model] = price
+			}
+		}
+	}
+	return merged
+}
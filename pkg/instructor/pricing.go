@@ -0,0 +1,173 @@
+package instructor
+
+import (
+	"strings"
+)
+
+// ModelPrice is the price of a model in US dollars per million tokens.
+//
+// CachedInput, CacheWrite and Reasoning apply to the input tokens served from
+// a prompt cache, the input tokens written to it and the output tokens spent on
+// reasoning, when the provider reports them. When zero, they fall back to Input
+// and Output respectively.
+type ModelPrice struct {
+	Input       float64
+	Output      float64
+	CachedInput float64
+	CacheWrite  float64
+	Reasoning   float64
+}
+
+// PriceTable maps a provider and model name to its price. Model names match
+// exactly or by prefix, so "gpt-4o" also prices "gpt-4o-2024-08-06".
+type PriceTable map[Provider]map[string]ModelPrice
+
+// Cost is the price of one or more provider calls, in US dollars.
+type Cost struct {
+	Input  float64
+	Output float64
+	Total  float64
+	// Unpriced is set when a call was made with a model missing from the price
+	// table, whose cost is counted as zero.
+	Unpriced bool
+}
+
+func (c *Cost) add(other Cost) {
+	c.Input += other.Input
+	c.Output += other.Output
+	c.Total += other.Total
+	c.Unpriced = c.Unpriced || other.Unpriced
+}
+
+// WithPrices overrides entries of the built-in price table used to compute the
+// cost of extractions. Entries not present in prices keep their default.
+func WithPrices(prices PriceTable) Options {
+	return Options{prices: prices}
+}
+
+// DefaultPriceTable returns a copy of the built-in price table.
+func DefaultPriceTable() PriceTable {
+	table := make(PriceTable, len(defaultPrices))
+	for provider, models := range defaultPrices {
+		table[provider] = make(map[string]ModelPrice, len(models))
+		for model, price := range models {
+			table[provider][model] = price
+		}
+	}
+	return table
+}
+
+// Lookup returns the price of model, matching the longest model name in the
+// table that model equals or starts with followed by a dash.
+func (t PriceTable) Lookup(provider Provider, model string) (ModelPrice, bool) {
+	models := t[provider]
+
+	if price, ok := models[model]; ok {
+		return price, true
+	}
+
+	var (
+		best  string
+		price ModelPrice
+	)
+	for name, p := range models {
+		if len(name) > len(best) && strings.HasPrefix(model, name+"-") {
+			best, price = name, p
+		}
+	}
+
+	return price, best != ""
+}
+
+// Cost returns the cost of usage at this price.
+func (p ModelPrice) Cost(usage Usage) Cost {
+	const perToken = 1.0 / 1_000_000
+
+	cached, cacheWrite, reasoning := p.CachedInput, p.CacheWrite, p.Reasoning
+	if cached == 0 {
+		cached = p.Input
+	}
+	if cacheWrite == 0 {
+		cacheWrite = p.Input
+	}
+	if reasoning == 0 {
+		reasoning = p.Output
+	}
+
+	input := usage.InputTokens - usage.CachedInputTokens - usage.CacheCreationTokens
+	output := usage.OutputTokens - usage.ReasoningTokens
+
+	c := Cost{
+		Input: (float64(input)*p.Input +
+			float64(usage.CachedInputTokens)*cached +
+			float64(usage.CacheCreationTokens)*cacheWrite) * perToken,
+		Output: (float64(output)*p.Output +
+			float64(usage.ReasoningTokens)*reasoning) * perToken,
+	}
+	c.Total = c.Input + c.Output
+
+	return c
+}
+
+// cost prices usage of model using the client's overrides, then the built-in table.
+func (o Options) cost(provider Provider, model string, usage Usage) Cost {
+	price, ok := o.prices.Lookup(provider, model)
+	if !ok {
+		price, ok = defaultPrices.Lookup(provider, model)
+	}
+	if !ok {
+		return Cost{Unpriced: true}
+	}
+	return price.Cost(usage)
+}
+
+var defaultPrices = PriceTable{
+	ProviderOpenAI: {
+		"gpt-5":         {Input: 1.25, CachedInput: 0.125, Output: 10.00},
+		"gpt-5-mini":    {Input: 0.25, CachedInput: 0.025, Output: 2.00},
+		"gpt-5-nano":    {Input: 0.05, CachedInput: 0.005, Output: 0.40},
+		"gpt-4.1":       {Input: 2.00, CachedInput: 0.50, Output: 8.00},
+		"gpt-4.1-mini":  {Input: 0.40, CachedInput: 0.10, Output: 1.60},
+		"gpt-4.1-nano":  {Input: 0.10, CachedInput: 0.025, Output: 0.40},
+		"gpt-4o":        {Input: 2.50, CachedInput: 1.25, Output: 10.00},
+		"gpt-4o-mini":   {Input: 0.15, CachedInput: 0.075, Output: 0.60},
+		"gpt-4-turbo":   {Input: 10.00, Output: 30.00},
+		"gpt-4":         {Input: 30.00, Output: 60.00},
+		"gpt-3.5-turbo": {Input: 0.50, Output: 1.50},
+		"o1":            {Input: 15.00, CachedInput: 7.50, Output: 60.00},
+		"o1-mini":       {Input: 1.10, CachedInput: 0.55, Output: 4.40},
+		"o3":            {Input: 2.00, CachedInput: 0.50, Output: 8.00},
+		"o3-mini":       {Input: 1.10, CachedInput: 0.55, Output: 4.40},
+		"o4-mini":       {Input: 1.10, CachedInput: 0.275, Output: 4.40},
+	},
+	ProviderAnthropic: {
+		"claude-opus-4-5":   {Input: 5.00, CachedInput: 0.50, CacheWrite: 6.25, Output: 25.00},
+		"claude-opus-4-1":   {Input: 15.00, CachedInput: 1.50, CacheWrite: 18.75, Output: 75.00},
+		"claude-opus-4":     {Input: 15.00, CachedInput: 1.50, CacheWrite: 18.75, Output: 75.00},
+		"claude-sonnet-4-5": {Input: 3.00, CachedInput: 0.30, CacheWrite: 3.75, Output: 15.00},
+		"claude-sonnet-4":   {Input: 3.00, CachedInput: 0.30, CacheWrite: 3.75, Output: 15.00},
+		"claude-haiku-4-5":  {Input: 1.00, CachedInput: 0.10, CacheWrite: 1.25, Output: 5.00},
+		"claude-3-7-sonnet": {Input: 3.00, CachedInput: 0.30, CacheWrite: 3.75, Output: 15.00},
+		"claude-3-5-sonnet": {Input: 3.00, CachedInput: 0.30, CacheWrite: 3.75, Output: 15.00},
+		"claude-3-5-haiku":  {Input: 0.80, CachedInput: 0.08, CacheWrite: 1.00, Output: 4.00},
+		"claude-3-opus":     {Input: 15.00, CachedInput: 1.50, CacheWrite: 18.75, Output: 75.00},
+		"claude-3-haiku":    {Input: 0.25, CachedInput: 0.03, CacheWrite: 0.3125, Output: 1.25},
+	},
+	ProviderGoogle: {
+		"gemini-2.5-pro":        {Input: 1.25, CachedInput: 0.31, Output: 10.00},
+		"gemini-2.5-flash":      {Input: 0.30, CachedInput: 0.075, Output: 2.50},
+		"gemini-2.5-flash-lite": {Input: 0.10, CachedInput: 0.025, Output: 0.40},
+		"gemini-2.0-flash":      {Input: 0.10, CachedInput: 0.025, Output: 0.40},
+		"gemini-2.0-flash-lite": {Input: 0.075, Output: 0.30},
+		"gemini-1.5-pro":        {Input: 1.25, CachedInput: 0.3125, Output: 5.00},
+		"gemini-1.5-flash":      {Input: 0.075, CachedInput: 0.01875, Output: 0.30},
+	},
+	ProviderCohere: {
+		"command-a":      {Input: 2.50, Output: 10.00},
+		"command-r-plus": {Input: 2.50, Output: 10.00},
+		"command-r":      {Input: 0.15, Output: 0.60},
+		"command-r7b":    {Input: 0.0375, Output: 0.15},
+		"command":        {Input: 1.00, Output: 2.00},
+		"command-light":  {Input: 0.30, Output: 0.60},
+	},
+}
@@ -0,0 +1,522 @@
+package instructor
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit limits the requests and tokens sent to a provider per minute.
+// Zero values are unlimited.
+type RateLimit struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+// limiter enforces a RateLimit with a token bucket per limit. Buckets start
+// full and refill continuously, so a minute's worth of requests may be sent
+// at once.
+type limiter struct {
+	mu       sync.Mutex
+	requests *bucket
+	tokens   *bucket
+}
+
+func newLimiter(limit RateLimit) *limiter {
+	return &limiter{
+		requests: newBucket(limit.RequestsPerMinute),
+		tokens:   newBucket(limit.TokensPerMinute),
+	}
+}
+
+// wait blocks until a request estimated to use tokens may be sent.
+func (l *limiter) wait(ctx context.Context, tokens int) error {
+	l.mu.Lock()
+	now := time.Now()
+	delay := max(l.requests.take(now, 1), l.tokens.take(now, float64(tokens)))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		l.cancel(tokens)
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// cancel gives back what wait took for a request estimated to use tokens
+// that is not sent.
+func (l *limiter) cancel(tokens int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.requests.give(1)
+	l.tokens.give(float64(tokens))
+}
+
+// settle corrects the tokens taken for a request by wait once its actual
+// usage is known.
+func (l *limiter) settle(estimated, actual int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens.give(float64(estimated - actual))
+}
+
+// bucket is a token bucket refilling perMinute tokens a minute. A nil bucket
+// is unlimited.
+type bucket struct {
+	capacity float64
+	// rate is the refill rate per second
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(perMinute int) *bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &bucket{
+		capacity: float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		tokens:   float64(perMinute),
+		last:     time.Now(),
+	}
+}
+
+// take takes n tokens, going into debt if needed, and returns how long to wait
+// for the debt to be paid back.
+func (b *bucket) take(now time.Time, n float64) time.Duration {
+	if b == nil {
+		return 0
+	}
+
+	b.refill(now)
+	// a request larger than the bucket would never fit
+	b.tokens -= min(n, b.capacity)
+
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+func (b *bucket) give(n float64) {
+	if b == nil {
+		return
+	}
+	b.tokens = min(b.tokens+n, b.capacity)
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed*b.rate, b.capacity)
+		b.last = now
+	}
+}
+
+// RateLimiter paces the requests sent to a provider with the rate limits the
+// provider reports. It learns the remaining requests and tokens from the
+// x-ratelimit-* and anthropic-ratelimit-* response headers, pauses callers
+// before the limits are hit, waits out retry-after on 429 responses and halves
+// its concurrency on them, growing it back on successful responses. Without a
+// maximum concurrency, the limit is lifted again once the provider reports
+// requests to spare, or a minute after the last 429 response when it does not
+// report them.
+//
+// A RateLimiter is used through the HTTP client of a provider, so it applies
+// to single extractions and batches alike:
+//
+//	limiter := instructor.SharedRateLimiter(instructor.ProviderOpenAI, apiKey)
+//
+//	config := openai.DefaultConfig(apiKey)
+//	config.HTTPClient = limiter.HTTPClient(nil)
+//	client := instructor.FromOpenAI(openai.NewClientWithConfig(config))
+//
+// It is safe for concurrent use.
+type RateLimiter struct {
+	mu     sync.Mutex
+	static *limiter
+
+	maxConcurrency int
+	// concurrency is the number of requests allowed in flight, 0 when unlimited
+	concurrency int
+	inFlight    int
+	// throttledAt is the time of the last 429 response
+	throttledAt time.Time
+	// wake is closed, and replaced, when waiting callers may proceed
+	wake chan struct{}
+
+	requests    quota
+	tokens      quota
+	pausedUntil time.Time
+}
+
+// quota is a remaining capacity reported by the provider, valid until reset.
+type quota struct {
+	remaining int
+	reset     time.Time
+}
+
+func (q quota) known(now time.Time) bool {
+	return now.Before(q.reset)
+}
+
+// RateLimiterState is the state of a RateLimiter, e.g. for health endpoints.
+type RateLimiterState struct {
+	// RemainingRequests and RemainingTokens are -1 when unknown.
+	RemainingRequests int
+	RemainingTokens   int
+	PausedUntil       time.Time
+	// Concurrency is the number of requests allowed in flight, 0 when unlimited.
+	Concurrency int
+	InFlight    int
+}
+
+// NewRateLimiter returns a RateLimiter enforcing limit on top of the limits
+// reported by the provider, with at most maxConcurrency requests in flight.
+// Zero values are unlimited. Requests are estimated from their size and
+// settled with the usage of their response once its body is closed, except for
+// streamed responses.
+func NewRateLimiter(limit RateLimit, maxConcurrency int) *RateLimiter {
+	return &RateLimiter{
+		static:         newLimiter(limit),
+		maxConcurrency: maxConcurrency,
+		concurrency:    maxConcurrency,
+		wake:           make(chan struct{}),
+	}
+}
+
+var sharedRateLimiters sync.Map
+
+// SharedRateLimiter returns the RateLimiter shared by every client of provider
+// using apiKey, as providers enforce their limits per key.
+func SharedRateLimiter(provider Provider, apiKey string) *RateLimiter {
+	sum := sha256.Sum256([]byte(apiKey))
+	key := string(provider) + "/" + hex.EncodeToString(sum[:])
+
+	l, _ := sharedRateLimiters.LoadOrStore(key, NewRateLimiter(RateLimit{}, 0))
+	return l.(*RateLimiter)
+}
+
+// State returns the current state of l.
+func (l *RateLimiter) State() RateLimiterState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	state := RateLimiterState{
+		RemainingRequests: -1,
+		RemainingTokens:   -1,
+		Concurrency:       l.concurrency,
+		InFlight:          l.inFlight,
+	}
+	if l.requests.known(now) {
+		state.RemainingRequests = l.requests.remaining
+	}
+	if l.tokens.known(now) {
+		state.RemainingTokens = l.tokens.remaining
+	}
+	if now.Before(l.pausedUntil) {
+		state.PausedUntil = l.pausedUntil
+	}
+	return state
+}
+
+// HTTPClient returns a copy of client, http.DefaultClient when nil, sending
+// its requests through l.
+func (l *RateLimiter) HTTPClient(client *http.Client) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	c := *client
+	c.Transport = l.Transport(c.Transport)
+	return &c
+}
+
+// Transport returns a RoundTripper sending requests through base,
+// http.DefaultTransport when nil, once l allows them.
+func (l *RateLimiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &rateLimitedTransport{limiter: l, base: base}
+}
+
+type rateLimitedTransport struct {
+	limiter *RateLimiter
+	base    http.RoundTripper
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the prompt is most of the request body, at roughly 4 bytes a token
+	tokens := int(max(req.ContentLength, 0) / 4)
+
+	if err := t.limiter.acquire(req.Context(), tokens); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.limiter.release()
+		return nil, err
+	}
+
+	t.limiter.observe(resp.StatusCode, resp.Header)
+
+	// responses are in flight until their body is closed, which for streamed
+	// ones is once they were read
+	body := &releaseBody{ReadCloser: resp.Body}
+	// with a token limit, JSON bodies are kept to settle it with the actual
+	// usage, unlike streamed ones, which can be long and report no usage here
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	body.keep = t.limiter.static.tokens != nil && mediaType == "application/json"
+	body.release = func() {
+		t.limiter.release()
+		if actual, ok := responseTokens(body.read.Bytes()); ok {
+			t.limiter.static.settle(tokens, actual)
+		}
+	}
+	resp.Body = body
+	return resp, nil
+}
+
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+
+	keep bool
+	read bytes.Buffer
+}
+
+func (b *releaseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if b.keep {
+		b.read.Write(p[:n])
+	}
+	return n, err
+}
+
+func (b *releaseBody) Close() error {
+	b.once.Do(b.release)
+	return b.ReadCloser.Close()
+}
+
+// responseTokens returns the tokens used by the OpenAI, Anthropic or Gemini
+// response body, which is not known for streamed responses.
+func responseTokens(body []byte) (int, bool) {
+	var resp struct {
+		Usage *struct {
+			TotalTokens  int `json:"total_tokens"`
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+		UsageMetadata *struct {
+			TotalTokenCount int `json:"totalTokenCount"`
+		} `json:"usageMetadata"`
+	}
+	if len(body) == 0 || json.Unmarshal(body, &resp) != nil {
+		return 0, false
+	}
+
+	switch {
+	case resp.Usage != nil && resp.Usage.TotalTokens > 0:
+		return resp.Usage.TotalTokens, true
+	case resp.Usage != nil:
+		return resp.Usage.InputTokens + resp.Usage.OutputTokens, true
+	case resp.UsageMetadata != nil:
+		return resp.UsageMetadata.TotalTokenCount, true
+	}
+	return 0, false
+}
+
+// acquire blocks until a request estimated to use tokens may be sent.
+func (l *RateLimiter) acquire(ctx context.Context, tokens int) error {
+	// no request is in flight while waiting for the static limit
+	if err := l.static.wait(ctx, tokens); err != nil {
+		return err
+	}
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+
+		var delay time.Duration
+		if now.Before(l.pausedUntil) {
+			delay = l.pausedUntil.Sub(now)
+		}
+		if l.requests.known(now) && l.requests.remaining <= 0 {
+			delay = max(delay, l.requests.reset.Sub(now))
+		}
+		if l.tokens.known(now) && l.tokens.remaining < tokens {
+			delay = max(delay, l.tokens.reset.Sub(now))
+		}
+
+		full := l.concurrency > 0 && l.inFlight >= l.concurrency
+		if delay <= 0 && !full {
+			l.inFlight++
+			// until the next response reports them
+			if l.requests.known(now) {
+				l.requests.remaining--
+			}
+			if l.tokens.known(now) {
+				l.tokens.remaining -= tokens
+			}
+			l.mu.Unlock()
+			return nil
+		}
+
+		wake := l.wake
+		l.mu.Unlock()
+
+		if err := sleep(ctx, delay, wake); err != nil {
+			l.static.cancel(tokens)
+			return err
+		}
+	}
+}
+
+// sleep waits for delay, when positive, or until wake is closed.
+func sleep(ctx context.Context, delay time.Duration, wake <-chan struct{}) error {
+	var timeout <-chan time.Time
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeout:
+	case <-wake:
+	}
+	return nil
+}
+
+func (l *RateLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	l.wakeAll()
+}
+
+// wakeAll wakes every waiting caller to check whether it may proceed.
+func (l *RateLimiter) wakeAll() {
+	close(l.wake)
+	l.wake = make(chan struct{})
+}
+
+// observe learns the limits reported by a response.
+func (l *RateLimiter) observe(status int, header http.Header) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	if remaining, reset, ok := parseRateLimit(now, header, "requests"); ok {
+		l.requests = quota{remaining: remaining, reset: reset}
+	}
+	if remaining, reset, ok := parseRateLimit(now, header, "tokens"); ok {
+		l.tokens = quota{remaining: remaining, reset: reset}
+	}
+
+	if status == http.StatusTooManyRequests {
+		wait, ok := parseRetryAfter(now, header)
+		if !ok {
+			wait = time.Second
+		}
+		l.pausedUntil = now.Add(wait)
+		l.throttledAt = now
+
+		l.concurrency = max(1, l.inFlight/2)
+	} else if status < 300 && l.concurrency > 0 {
+		l.concurrency++
+		switch {
+		case l.maxConcurrency > 0:
+			l.concurrency = min(l.concurrency, l.maxConcurrency)
+		case l.recovered(now):
+			l.concurrency = 0
+		}
+	}
+
+	l.wakeAll()
+}
+
+// recovered reports whether the concurrency limit set by a 429 response may be
+// lifted: once the provider reports more remaining requests than it allows in
+// flight, or a minute later when it does not report them.
+func (l *RateLimiter) recovered(now time.Time) bool {
+	if l.requests.known(now) {
+		return l.requests.remaining > l.concurrency
+	}
+	return now.Sub(l.throttledAt) >= time.Minute
+}
+
+// parseRateLimit parses the remaining capacity and reset time of kind,
+// "requests" or "tokens", from the OpenAI or Anthropic rate limit headers.
+func parseRateLimit(now time.Time, header http.Header, kind string) (int, time.Time, bool) {
+	if v := header.Get("x-ratelimit-remaining-" + kind); v != "" {
+		remaining, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, time.Time{}, false
+		}
+		// resets are durations, such as "1s" or "6m0s"
+		reset, err := time.ParseDuration(header.Get("x-ratelimit-reset-" + kind))
+		if err != nil {
+			reset = time.Minute
+		}
+		return remaining, now.Add(reset), true
+	}
+
+	if v := header.Get("anthropic-ratelimit-" + kind + "-remaining"); v != "" {
+		remaining, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, time.Time{}, false
+		}
+		// resets are RFC 3339 times
+		reset, err := time.Parse(time.RFC3339, header.Get("anthropic-ratelimit-"+kind+"-reset"))
+		if err != nil {
+			reset = now.Add(time.Minute)
+		}
+		return remaining, reset, true
+	}
+
+	return 0, time.Time{}, false
+}
+
+// parseRetryAfter parses the retry-after-ms or retry-after header, the latter
+// in seconds or as an HTTP date.
+func parseRetryAfter(now time.Time, header http.Header) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(header.Get("retry-after-ms"), 64); err == nil {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+
+	v := header.Get("retry-after")
+	if seconds, err := strconv.ParseFloat(v, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return t.Sub(now), true
+	}
+	return 0, false
+}
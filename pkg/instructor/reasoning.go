@@ -0,0 +1,57 @@
+package instructor
+
+import (
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// WithReasoning records the reasoning of reasoning models into
+// Metadata.Reasoning: the thinking blocks of Claude extended thinking and the
+// thought parts of Gemini thinking models, when the request asks for them.
+// OpenAI returns no reasoning text. The reasoning tokens are counted in
+// Usage.ReasoningTokens whether or not it is given.
+func WithReasoning() Options {
+	return Options{reasoning: toPtr(true)}
+}
+
+// reasoner is implemented by clients returning the reasoning of a response.
+type reasoner interface {
+	reasoning(response interface{}) string
+}
+
+// isOpenAIReasoningModel reports whether model is an OpenAI reasoning model.
+// The chat models of GPT-5, such as gpt-5-chat-latest, do not reason.
+func isOpenAIReasoningModel(model string) bool {
+	if strings.HasPrefix(model, "gpt-5") && strings.Contains(model, "-chat") {
+		return false
+	}
+	return restrictsOpenAIParameters(model)
+}
+
+// restrictsOpenAIParameters reports whether requests to model only take the
+// parameters of reasoning models: max_completion_tokens instead of max_tokens,
+// no sampling parameters and no log probabilities. go-openai enforces it for
+// every GPT-5 model, the chat ones included.
+func restrictsOpenAIParameters(model string) bool {
+	for _, prefix := range []string{"o1", "o3", "o4", "gpt-5"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// openAIInstructionRole returns the role of the instructions sent to model:
+// the developer role replaces the system role for reasoning models, and the
+// earliest ones only accept user messages.
+func openAIInstructionRole(model string) string {
+	switch {
+	case strings.HasPrefix(model, "o1-mini"), strings.HasPrefix(model, "o1-preview"):
+		return openai.ChatMessageRoleUser
+	case isOpenAIReasoningModel(model):
+		return openai.ChatMessageRoleDeveloper
+	default:
+		return openai.ChatMessageRoleSystem
+	}
+}
@@ -0,0 +1,161 @@
+package instructor
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"path"
+)
+
+// Extractor extracts structured data with a provider-neutral Request. Every
+// Instructor client is an Extractor, so call sites do not depend on a vendor:
+//
+//	var person Person
+//	resp, err := client.Extract(ctx, instructor.Request{
+//		Model:    "gpt-4o",
+//		Messages: []instructor.Message{instructor.UserMessage("Robby is 22 years old.")},
+//	}, &person)
+//
+// The native entry points, such as InstructorOpenAI.CreateChatCompletion,
+// remain available for provider specific features. Their native requests
+// cannot be converted between providers, so features switching to another
+// provider, such as WithEscalation and WithHedging, require a Request.
+type Extractor interface {
+	Extract(ctx context.Context, request Request, responseType any) (*Response, error)
+}
+
+type Role = string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Request is a provider-neutral extraction request.
+type Request struct {
+	Model string
+	// System is the system prompt. System messages in Messages are appended to it
+	// for providers that only accept a single system prompt.
+	System   string
+	Messages []Message
+
+	Temperature *float32
+	// MaxTokens limits the output tokens. Providers requiring a limit default
+	// to DefaultMaxTokens.
+	MaxTokens int
+}
+
+const DefaultMaxTokens = 4096
+
+type Message struct {
+	Role    Role
+	Content string
+	Images  []Image
+}
+
+// Image is an image attached to a message, either by URL or by content.
+type Image struct {
+	URL string
+
+	Data []byte
+	// MediaType is the MIME type of Data, e.g. "image/png". For URLs, it is
+	// guessed from the file extension when empty.
+	MediaType string
+}
+
+func SystemMessage(content string) Message {
+	return Message{Role: RoleSystem, Content: content}
+}
+
+func UserMessage(content string, images ...Image) Message {
+	return Message{Role: RoleUser, Content: content, Images: images}
+}
+
+func AssistantMessage(content string) Message {
+	return Message{Role: RoleAssistant, Content: content}
+}
+
+func ImageFromURL(url string) Image {
+	return Image{URL: url}
+}
+
+func ImageFromData(data []byte, mediaType string) Image {
+	return Image{Data: data, MediaType: mediaType}
+}
+
+// Response is the result of Extract.
+type Response struct {
+	Metadata
+
+	// Raw is the native provider response, e.g. *openai.ChatCompletionResponse.
+	Raw interface{}
+}
+
+func extract(i Instructor, ctx context.Context, request Request, responseType any) (*Response, error) {
+	native, err := i.nativeRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// keep filling in metadata requested by the caller
+	parent := metadataFromContext(ctx)
+
+	var md Metadata
+	raw, err := handleChat(i, WithMetadata(ctx, &md), native, &request, responseType)
+
+	if parent != nil {
+		*parent = md
+	}
+
+	return &Response{Metadata: md, Raw: raw}, err
+}
+
+// system returns the system prompt followed by the content of system messages.
+func (r Request) system() string {
+	system := r.System
+	for _, m := range r.Messages {
+		if m.Role != RoleSystem {
+			continue
+		}
+		if system != "" {
+			system += "\n\n"
+		}
+		system += m.Content
+	}
+	return system
+}
+
+func (r Request) maxTokens() int {
+	if r.MaxTokens > 0 {
+		return r.MaxTokens
+	}
+	return DefaultMaxTokens
+}
+
+func (img Image) mediaType() string {
+	if img.MediaType != "" || img.URL == "" {
+		return img.MediaType
+	}
+	return mime.TypeByExtension(path.Ext(img.URL))
+}
+
+// dataURL returns the URL of img, encoding its data if needed.
+func (img Image) dataURL() string {
+	if img.URL != "" {
+		return img.URL
+	}
+	return fmt.Sprintf("data:%s;base64,%s", img.MediaType, base64.StdEncoding.EncodeToString(img.Data))
+}
+
+func (img Image) validate() error {
+	switch {
+	case img.URL == "" && len(img.Data) == 0:
+		return errors.New("image has neither URL nor data")
+	case len(img.Data) > 0 && img.MediaType == "":
+		return errors.New("image data requires a media type")
+	}
+	return nil
+}
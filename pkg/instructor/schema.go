@@ -74,3 +74,13 @@ func ToFunctionSchema(tType reflect.Type, tSchema *jsonschema.Schema) []Function
 func (s *Schema) NameFromRef() string {
 	return strings.Split(s.Ref, "/")[2] // ex: '#/$defs/MyStruct'
 }
+
+// Name returns the name of the referenced type, or an empty string when the
+// schema has no reference (e.g. anonymous types).
+func (s *Schema) Name() string {
+	parts := strings.Split(s.Ref, "/")
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[2]
+}
@@ -0,0 +1,152 @@
+package instructor
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	DefaultMaxContinuations = 3
+	DefaultMaxOutputTokens  = 16384
+)
+
+// TruncationStrategy is how an output cut at the output token limit is handled.
+type TruncationStrategy string
+
+const (
+	// TruncationContinue asks the model to continue the output where it was
+	// cut, and stitches the continuations onto it.
+	TruncationContinue TruncationStrategy = "continue"
+	// TruncationRaiseLimit retries with twice the output token limit.
+	TruncationRaiseLimit TruncationStrategy = "raise_limit"
+	// TruncationFail fails the extraction with a TruncatedOutputError.
+	TruncationFail TruncationStrategy = "fail"
+)
+
+// Truncation configures the handling of truncated outputs.
+type Truncation struct {
+	// Strategy is how truncated outputs are handled, TruncationContinue when empty.
+	Strategy TruncationStrategy
+	// MaxContinuations is the number of continuations of an output,
+	// DefaultMaxContinuations when zero.
+	MaxContinuations int
+	// MaxTokens is the output token limit raised limits are capped at,
+	// DefaultMaxOutputTokens when zero.
+	MaxTokens int
+}
+
+// WithTruncation handles outputs cut at the output token limit of the request,
+// which would otherwise fail to decode and be retried as is, truncating again:
+//
+//	client := instructor.FromOpenAI(openai.NewClient(key),
+//		instructor.WithMode(instructor.ModeJSONSchema),
+//		instructor.WithTruncation(instructor.Truncation{Strategy: instructor.TruncationContinue}),
+//	)
+//
+// OpenAI and Anthropic clients support every strategy. Continuations are
+// only possible for text outputs: in ModeJSONSchema, and in ModeJSON for
+// Anthropic unless extended thinking is enabled. Outputs that cannot be
+// continued, or are still truncated after MaxContinuations, are retried with
+// a raised limit. Other clients fail fast with a TruncatedOutputError.
+// Raised limits share the retry budget of the client, and streaming
+// extractions are not handled.
+func WithTruncation(truncation Truncation) Options {
+	return Options{truncation: &truncation}
+}
+
+// TruncatedOutputError is returned when an output was cut at the output token
+// limit and could not be completed.
+type TruncatedOutputError struct {
+	// Output is the partial output.
+	Output       string
+	FinishReason string
+}
+
+func (e *TruncatedOutputError) Error() string {
+	return fmt.Sprintf("output truncated at the output token limit (finish reason %q)", e.FinishReason)
+}
+
+// truncator is implemented by clients completing truncated outputs.
+type truncator interface {
+	// continueChat continues output, the truncated output of request,
+	// returning it with the continuation appended, or false when the output
+	// cannot be continued.
+	continueChat(ctx context.Context, request interface{}, schema *Schema, output string) (string, interface{}, bool, error)
+	// raiseLimit returns a copy of request with a higher output token limit,
+	// given the output tokens used, up to ceiling, or false when it is already
+	// at ceiling.
+	raiseLimit(request interface{}, used int, ceiling int) (interface{}, bool)
+}
+
+// continuePrompt asks to continue a truncated output, for providers that
+// cannot prefill the response with it.
+const continuePrompt = "Your previous response was cut off. Continue it exactly where it stopped, without repeating any of it or adding anything before it."
+
+// truncated reports whether finishReason is the one of an output cut at the
+// output token limit by any provider.
+func truncated(finishReason string) bool {
+	switch finishReason {
+	case "length", "max_tokens", "MAX_TOKENS":
+		return true
+	}
+	return false
+}
+
+// continueOutput continues text, the output of resp to request, while it is
+// truncated. check is called with the usage of the calls made so far before
+// every continuation, which is not made when it fails. The returned response
+// has the usage of every call.
+func (t *Truncation) continueOutput(ctx context.Context, client Instructor, request interface{}, schema *Schema, text string, resp interface{}, check func(spent Usage) error) (string, interface{}, error) {
+	c, ok := client.(truncator)
+	if !ok || t.Strategy != "" && t.Strategy != TruncationContinue {
+		return text, resp, nil
+	}
+
+	continuations := t.MaxContinuations
+	if continuations <= 0 {
+		continuations = DefaultMaxContinuations
+	}
+
+	for n := 0; n < continuations && truncated(client.finishReason(resp)); n++ {
+		usage := client.countUsageFromResponse(resp, &Usage{})
+		if err := check(*usage); err != nil {
+			return "", client.emptyResponseWithUsageSum(usage), err
+		}
+
+		next, nextResp, ok, err := c.continueChat(ctx, request, schema, text)
+		if !ok {
+			break
+		}
+
+		if err != nil {
+			return "", client.emptyResponseWithUsageSum(client.countUsageFromResponse(nextResp, usage)), err
+		}
+		if resp, err = client.addUsageSumToResponse(nextResp, usage); err != nil {
+			return "", resp, err
+		}
+		text = next
+	}
+	return text, resp, nil
+}
+
+// raiseLimit returns request with a raised output token limit, or false when
+// the extraction must fail with the truncated output.
+func (t *Truncation) raiseLimit(client Instructor, request interface{}, used int) (interface{}, bool) {
+	c, ok := client.(truncator)
+	if !ok || t.Strategy == TruncationFail {
+		return nil, false
+	}
+
+	ceiling := t.MaxTokens
+	if ceiling <= 0 {
+		ceiling = DefaultMaxOutputTokens
+	}
+	return c.raiseLimit(request, used, ceiling)
+}
+
+// raisedLimit doubles limit, or used when no limit is set, up to ceiling.
+func raisedLimit(limit, used, ceiling int) (int, bool) {
+	current := max(limit, used)
+	raised := min(2*current, ceiling)
+	return raised, raised > current
+}
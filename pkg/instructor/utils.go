@@ -1,6 +1,7 @@
 package instructor
 
 import (
+	"encoding/json"
 	"strings"
 )
 
@@ -8,6 +9,14 @@ func toPtr[T any](val T) *T {
 	return &val
 }
 
+func valueOf[T any](ptr *T) T {
+	if ptr == nil {
+		var zero T
+		return zero
+	}
+	return *ptr
+}
+
 func prepend[T any](to []T, from T) []T {
 	return append([]T{from}, to...)
 }
@@ -55,6 +64,21 @@ func getFirstFullJSONElement(json *string) (element string, remaining string) {
 	return element, remaining
 }
 
+// unwrapJSON returns the value of key in the JSON object text. Text without key
+// is returned unchanged, so that it fails to decode and is retried rather than
+// decoding into a zero value.
+func unwrapJSON(text string, key string) string {
+	var wrapper map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(text), &wrapper); err != nil {
+		return text
+	}
+	value, ok := wrapper[key]
+	if !ok {
+		return text
+	}
+	return string(value)
+}
+
 // Removes any prefixes before the JSON (like "Sure, here you go:")
 func trimPrefixBeforeJSON(json *string) string {
 	startObject := strings.IndexByte(*json, '{')
@@ -0,0 +1,138 @@
+package cassette_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	"github.com/instructor-ai/instructor-go/pkg/instructor/cassette"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type Person struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("missing api key")
+		}
+
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+				Model: openai.GPT4o,
+				Choices: []openai.ChatCompletionChoice{{
+					Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: `{"name": "Robby", "age": 22}`},
+					FinishReason: openai.FinishReasonStop,
+				}},
+				Usage: openai.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+			})
+		case "/v1/events":
+			w.Header().Set("Content-Type", "text/event-stream")
+			for idx := 0; idx < 3; idx++ {
+				fmt.Fprintf(w, "data: %d\n\n", idx)
+				w.(http.Flusher).Flush()
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func extract(t *testing.T, rec *cassette.Recorder, baseURL string) Person {
+	t.Helper()
+
+	config := openai.DefaultConfig("secret")
+	config.BaseURL = baseURL + "/v1"
+	client := instructor.FromOpenAI(rec.OpenAIWithConfig(config), instructor.WithMode(instructor.ModeJSON))
+
+	var person Person
+	_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT4o,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Robby is 22 years old."}},
+	}, &person)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return person
+}
+
+func stream(t *testing.T, rec *cassette.Recorder, baseURL string) string {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, baseURL+"/v1/events", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := rec.HTTPClient().Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+	return string(b)
+}
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	srv := newServer(t)
+
+	rec, err := cassette.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Recording() {
+		t.Fatal("expected a missing cassette to be recorded")
+	}
+	recorded := extract(t, rec, srv.URL)
+	events := stream(t, rec, srv.URL)
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	baseURL := srv.URL
+	srv.Close()
+
+	if b, _ := os.ReadFile(path); strings.Contains(string(b), "secret") {
+		t.Error("api key written to cassette")
+	}
+
+	rec, err = cassette.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Recording() {
+		t.Fatal("expected an existing cassette to be replayed")
+	}
+
+	if replayed := extract(t, rec, baseURL); replayed != recorded {
+		t.Errorf("replayed %+v, recorded %+v", replayed, recorded)
+	}
+	if replayed := stream(t, rec, baseURL); replayed != events || replayed != "data: 0\n\ndata: 1\n\ndata: 2\n\n" {
+		t.Errorf("replayed stream %q, recorded %q", replayed, events)
+	}
+
+	// every interaction is replayed once
+	if _, err := rec.HTTPClient().Get(baseURL + "/v1/events"); err == nil {
+		t.Error("expected an error for a request without an unused interaction")
+	}
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	_, err := cassette.New(filepath.Join(t.TempDir(), "missing.json"), cassette.WithMode(cassette.ModeReplay))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,205 @@
+package instructor_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	anthropic "github.com/liushuangls/go-anthropic/v2"
+)
+
+// newFakeAnthropicBatches fakes the Message Batches endpoints of the Anthropic
+// API, ending the batch on the second poll with the results given as JSONL
+// lines. The returned func returns the batch created.
+func newFakeAnthropicBatches(t *testing.T, results ...string) (*httptest.Server, func() anthropic.BatchRequest) {
+	var mu sync.Mutex
+	var created anthropic.BatchRequest
+	polls := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/messages/batches", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		_ = json.Unmarshal(b, &created)
+		mu.Unlock()
+		writeJSON(w, anthropic.BatchRespCore{Id: "msgbatch_1", ProcessingStatus: anthropic.ProcessingStatusInProgress})
+	})
+	mux.HandleFunc("GET /v1/messages/batches/msgbatch_1", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+		status := anthropic.ProcessingStatusInProgress
+		if polls >= 2 {
+			status = anthropic.ProcessingStatusEnded
+		}
+		writeJSON(w, anthropic.BatchRespCore{Id: "msgbatch_1", ProcessingStatus: status})
+	})
+	mux.HandleFunc("GET /v1/messages/batches/msgbatch_1/results", func(w http.ResponseWriter, r *http.Request) {
+		for _, line := range results {
+			fmt.Fprintf(w, "%s\n", line)
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, func() anthropic.BatchRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return created
+	}
+}
+
+func anthropicBatchSuccess(id string, input string) string {
+	b, _ := json.Marshal(map[string]any{
+		"custom_id": id,
+		"result": map[string]any{
+			"type": "succeeded",
+			"message": map[string]any{
+				"id":          "msg_" + id,
+				"type":        "message",
+				"role":        "assistant",
+				"model":       "claude-3-5-haiku-latest",
+				"content":     []any{map[string]any{"type": "tool_use", "id": "toolu_1", "name": "ConformancePerson", "input": jsonObject(input)}},
+				"stop_reason": "tool_use",
+				"usage":       map[string]any{"input_tokens": 6, "output_tokens": 5, "cache_read_input_tokens": 3},
+			},
+		},
+	})
+	return string(b)
+}
+
+func TestAnthropicBatch(t *testing.T) {
+	srv, created := newFakeAnthropicBatches(t,
+		anthropicBatchSuccess("robby", `{"name": "Robby", "age": 22}`),
+		anthropicBatchSuccess("lucy", `{"name": "Lucy", "age": 200}`),
+		`{"custom_id": "tom", "result": {"type": "errored", "error": {"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}}}`,
+		`{"custom_id": "anna", "result": {"type": "expired"}}`,
+	)
+
+	client := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")),
+		instructor.WithMode(instructor.ModeToolCall),
+		instructor.WithValidation(),
+	)
+
+	var requests []instructor.AnthropicBatchRequest
+	for _, id := range []string{"anna", "robby", "lucy", "tom", "zoe"} {
+		requests = append(requests, instructor.AnthropicBatchRequest{
+			CustomID: id,
+			Request: anthropic.MessagesRequest{
+				Model:     "claude-3-5-haiku-latest",
+				Messages:  []anthropic.Message{anthropic.NewUserTextMessage("Who is " + id + "?")},
+				MaxTokens: 500,
+			},
+		})
+	}
+
+	ctx := context.Background()
+	batch, err := instructor.SubmitAnthropicBatch[ConformancePerson](ctx, client, requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c := created(); len(c.Requests) != 5 || len(c.Requests[0].Params.Tools) != 1 || c.Requests[0].CustomId != "anna" {
+		t.Fatalf("created batch = %+v, want 5 requests with the tool", c)
+	}
+
+	batch, err = instructor.WaitAnthropicBatch(ctx, client, batch.Id, time.Millisecond)
+	if err != nil || batch.ProcessingStatus != anthropic.ProcessingStatusEnded {
+		t.Fatalf("batch = %+v, err = %v", batch, err)
+	}
+
+	list, err := instructor.AnthropicBatchResults[ConformancePerson](ctx, client, batch.Id, requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := map[string]instructor.AnthropicBatchResult[ConformancePerson]{}
+	for k, r := range list {
+		if r.CustomID != requests[k].CustomID {
+			t.Errorf("result %d is %q, want the order of requests", k, r.CustomID)
+		}
+		results[r.CustomID] = r
+	}
+
+	if r := results["robby"]; r.Err != nil || r.Value.Name != "Robby" || r.Usage.InputTokens != 9 || r.Usage.CachedInputTokens != 3 {
+		t.Errorf("robby: %+v", r)
+	}
+	if r := results["lucy"]; r.Err == nil || r.Response == nil || r.Usage.OutputTokens != 5 {
+		t.Errorf("lucy: want a validation error with usage, got %+v", r)
+	}
+	var apiErr *anthropic.APIError
+	if r := results["tom"]; !errors.As(r.Err, &apiErr) || !apiErr.IsOverloadedErr() {
+		t.Errorf("tom: want an overloaded error, got %+v", r)
+	}
+	if r := results["anna"]; r.Err == nil {
+		t.Errorf("anna: want an expired error, got %+v", r)
+	}
+	if r := results["zoe"]; r.Err == nil {
+		t.Errorf("zoe: want a missing result error, got %+v", r)
+	}
+}
+
+func TestRetryAnthropicBatch(t *testing.T) {
+	srv := newAnthropicServer(t, `{"name": "Lucy", "age": 20}`)
+	client := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")),
+		instructor.WithMode(instructor.ModeToolCall),
+		instructor.WithValidation(),
+	)
+
+	requests := []instructor.AnthropicBatchRequest{
+		{CustomID: "robby", Request: anthropic.MessagesRequest{Model: "claude-3-5-haiku-latest", MaxTokens: 500}},
+		{CustomID: "lucy", Request: anthropic.MessagesRequest{Model: "claude-3-5-haiku-latest", MaxTokens: 500}},
+		{CustomID: "ben", Request: anthropic.MessagesRequest{Model: "claude-3-5-haiku-latest", MaxTokens: 500}},
+	}
+	results, err := instructor.ReadAnthropicBatchResults[ConformancePerson](client, strings.NewReader(
+		anthropicBatchSuccess("robby", `{"name": "Robby", "age": 22}`)+"\n"+
+			anthropicBatchSuccess("lucy", `{"name": "Lucy", "age": 200}`)+"\n"+
+			`{"custom_id": "ben", "result": {"type": "succeeded", "message": {"content": "not a list"}}}`+"\n",
+	), requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := results[2]; r.CustomID != "ben" || r.Err == nil {
+		t.Errorf("ben: want the error of a result that does not decode, got %+v", r)
+	}
+
+	// only the failed result is retried, found by its ID
+	results = results[1:2]
+	if err := instructor.RetryAnthropicBatch(context.Background(), client, requests, results); err != nil {
+		t.Fatal(err)
+	}
+	if r := results[0]; r.Err != nil || r.Value.Name != "Lucy" || r.Value.Age != 20 || r.Usage.OutputTokens != 5 {
+		t.Errorf("lucy: %+v", r)
+	}
+	if srv.Calls() != 1 {
+		t.Errorf("calls = %d, want only the failed result retried", srv.Calls())
+	}
+
+	results[0].Err = errors.New("failed")
+	results[0].CustomID = "tom"
+	if err := instructor.RetryAnthropicBatch(context.Background(), client, requests, results); err == nil {
+		t.Error("expected results without a request to be rejected")
+	}
+}
+
+func TestAnthropicBatchJSONSchema(t *testing.T) {
+	client := instructor.FromAnthropic(anthropic.NewClient("test"), instructor.WithMode(instructor.ModeJSONSchema))
+
+	batch, err := instructor.NewAnthropicBatch[ConformancePerson](client, []instructor.AnthropicBatchRequest{
+		{CustomID: "a", Request: anthropic.MessagesRequest{Model: "claude-3-5-haiku-latest", System: "You extract people."}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params := batch.Requests[0].Params; len(params.Tools) != 0 || len(params.System) <= len("You extract people.") {
+		t.Errorf("batch request has no JSON schema instructions: %+v", params)
+	}
+}
@@ -0,0 +1,121 @@
+package instructor_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	anthropic "github.com/liushuangls/go-anthropic/v2"
+)
+
+func anthropicJSONRequest() anthropic.MessagesRequest {
+	return anthropic.MessagesRequest{
+		Model:     anthropic.ModelClaude3Haiku20240307,
+		Messages:  []anthropic.Message{anthropic.NewUserTextMessage("Robby is 22 years old, Lucy is 31.")},
+		MaxTokens: 500,
+	}
+}
+
+// lastMessage returns the role and text of the last message of an Anthropic request body.
+func lastMessage(body map[string]any) (string, string) {
+	messages, _ := body["messages"].([]any)
+	last, _ := messages[len(messages)-1].(map[string]any)
+	content, _ := last["content"].([]any)
+	block, _ := content[0].(map[string]any)
+	role, _ := last["role"].(string)
+	text, _ := block["text"].(string)
+	return role, text
+}
+
+func TestAnthropicJSONMode(t *testing.T) {
+	srv := newAnthropicServer(t, `"name": "Robby", "age": 22}`)
+	client := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")), instructor.WithMode(instructor.ModeJSON))
+
+	var person ConformancePerson
+	if _, err := client.CreateMessages(context.Background(), anthropicJSONRequest(), &person); err != nil {
+		t.Fatal(err)
+	}
+	if person.Name != "Robby" || person.Age != 22 {
+		t.Errorf("got %+v", person)
+	}
+
+	if role, text := lastMessage(srv.Request(0)); role != "assistant" || text != "{" {
+		t.Errorf("last message = %s %q, want the assistant prefill", role, text)
+	}
+	if _, ok := srv.Request(0)["tools"]; ok {
+		t.Error("JSON mode sent tools")
+	}
+}
+
+func TestAnthropicJSONModeSlice(t *testing.T) {
+	srv := newAnthropicServer(t, `{"name": "Robby", "age": 22}, {"name": "Lucy", "age": 31}]`)
+	client := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")), instructor.WithMode(instructor.ModeJSON))
+
+	var people []ConformancePerson
+	if _, err := client.CreateMessages(context.Background(), anthropicJSONRequest(), &people); err != nil {
+		t.Fatal(err)
+	}
+	if len(people) != 2 || people[1].Name != "Lucy" {
+		t.Errorf("got %+v", people)
+	}
+	if _, text := lastMessage(srv.Request(0)); text != "[" {
+		t.Errorf("prefill = %q, want [", text)
+	}
+}
+
+func TestAnthropicJSONModeRepeatedPrefill(t *testing.T) {
+	// the model may repeat the bracket it was prefilled with
+	srv := newAnthropicServer(t, `[{"name": "Robby", "age": 22}]`)
+	client := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")), instructor.WithMode(instructor.ModeJSON))
+
+	var people []ConformancePerson
+	if _, err := client.CreateMessages(context.Background(), anthropicJSONRequest(), &people); err != nil {
+		t.Fatal(err)
+	}
+	if len(people) != 1 || people[0].Name != "Robby" {
+		t.Errorf("got %+v", people)
+	}
+
+	// the elements of nested arrays start with a bracket too
+	for _, output := range []string{`[1, 2], [3]]`, ` [[1, 2], [3]]`} {
+		srv := newAnthropicServer(t, output)
+		client := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")), instructor.WithMode(instructor.ModeJSON))
+
+		var rows [][]int
+		if _, err := client.CreateMessages(context.Background(), anthropicJSONRequest(), &rows); err != nil {
+			t.Fatalf("%s: %v", output, err)
+		}
+		if len(rows) != 2 || len(rows[0]) != 2 || rows[1][0] != 3 {
+			t.Errorf("%s: got %v", output, rows)
+		}
+	}
+}
+
+func TestAnthropicJSONModeTextBlocks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"id":   "msg_1",
+			"type": "message",
+			"role": "assistant",
+			"content": []any{
+				map[string]any{"type": "text", "text": `"name": "Robby",`},
+				map[string]any{"type": "text", "text": ` "age": 22}`},
+			},
+			"stop_reason": "end_turn",
+			"usage":       map[string]any{"input_tokens": 6, "output_tokens": 5},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	client := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")), instructor.WithMode(instructor.ModeJSON))
+
+	var person ConformancePerson
+	if _, err := client.CreateMessages(context.Background(), anthropicJSONRequest(), &person); err != nil {
+		t.Fatal(err)
+	}
+	if person.Name != "Robby" || person.Age != 22 {
+		t.Errorf("got %+v from the concatenated text blocks", person)
+	}
+}
@@ -0,0 +1,194 @@
+package instructor_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+)
+
+// extractorFunc extracts with a function, for batches whose items must not
+// depend on the order calls are made in.
+type extractorFunc func(ctx context.Context, request instructor.Request, responseType any) (*instructor.Response, error)
+
+func (f extractorFunc) Extract(ctx context.Context, request instructor.Request, responseType any) (*instructor.Response, error) {
+	return f(ctx, request, responseType)
+}
+
+func personRequests(n int) []instructor.Request {
+	requests := make([]instructor.Request, n)
+	for idx := range requests {
+		requests[idx] = instructor.Request{Messages: []instructor.Message{instructor.UserMessage(fmt.Sprint(idx))}}
+	}
+	return requests
+}
+
+func TestExtractBatch(t *testing.T) {
+	var running, peak atomic.Int32
+
+	extractor := extractorFunc(func(ctx context.Context, request instructor.Request, responseType any) (*instructor.Response, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+
+		var age int
+		fmt.Sscan(request.Messages[0].Content, &age)
+		// later requests finish first
+		time.Sleep(time.Duration(20-age) * time.Millisecond)
+
+		resp := &instructor.Response{Metadata: instructor.Metadata{Usage: instructor.Usage{TotalTokens: 10}}}
+		if age%5 == 0 {
+			return resp, errors.New("provider error")
+		}
+		return resp, json.Unmarshal([]byte(fmt.Sprintf(`{"name": "Robby", "age": %d}`, age)), responseType)
+	})
+
+	var last instructor.BatchProgress
+	calls := 0
+	results := instructor.ExtractBatch[ConformancePerson](context.Background(), extractor, personRequests(20), instructor.BatchOptions{
+		Concurrency: 4,
+		Progress: func(p instructor.BatchProgress) {
+			calls++
+			last = p
+		},
+	})
+
+	for idx, r := range results {
+		if r.Index != idx {
+			t.Fatalf("result %d has index %d", idx, r.Index)
+		}
+		if idx%5 == 0 {
+			if r.Err == nil || r.Value != nil {
+				t.Errorf("result %d = %+v, want an error", idx, r)
+			}
+			continue
+		}
+		if r.Err != nil || r.Value.Age != idx {
+			t.Errorf("result %d = %+v, err %v", idx, r.Value, r.Err)
+		}
+	}
+
+	if p := peak.Load(); p > 4 {
+		t.Errorf("ran %d extractions at once, want at most 4", p)
+	}
+	if calls != 20 || last.Done != 20 || last.Failed != 4 || last.Total != 20 || last.Usage.TotalTokens != 200 {
+		t.Errorf("progress = %+v after %d calls", last, calls)
+	}
+}
+
+func TestExtractBatchStream(t *testing.T) {
+	mock := instructor.NewMock().Return(`{"name": "Robby", "age": 22}`, `{"name": "Lucy", "age": 21}`, `not json`)
+
+	requests := make(chan instructor.Request)
+	go func() {
+		defer close(requests)
+		for _, r := range personRequests(3) {
+			requests <- r
+		}
+	}()
+
+	names := []string{}
+	for r := range instructor.ExtractBatchStream[ConformancePerson](context.Background(), mock, requests, instructor.BatchOptions{Concurrency: 1}) {
+		if r.Err != nil {
+			names = append(names, "error")
+			continue
+		}
+		names = append(names, r.Value.Name)
+	}
+
+	if strings.Join(names, ",") != "Robby,Lucy,error" {
+		t.Errorf("results = %v", names)
+	}
+}
+
+func TestExtractBatchStreamCancel(t *testing.T) {
+	extractor := extractorFunc(func(ctx context.Context, request instructor.Request, responseType any) (*instructor.Response, error) {
+		return &instructor.Response{}, json.Unmarshal([]byte(`{"name": "Robby", "age": 22}`), responseType)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// requests that do not end before ctx does
+	requests := make(chan instructor.Request)
+	go func() {
+		for {
+			select {
+			case requests <- personRequests(1)[0]:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	results := instructor.ExtractBatchStream[ConformancePerson](ctx, extractor, requests, instructor.BatchOptions{Concurrency: 4})
+	<-results
+	// the consumer stops reading
+	cancel()
+
+	closed := make(chan struct{})
+	go func() {
+		for range results {
+		}
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("results were not closed after ctx was canceled")
+	}
+}
+
+func TestExtractBatchTokenLimit(t *testing.T) {
+	// every request uses slightly more than the per minute limit, so the next
+	// one waits for the excess to refill, at 1000 tokens a second
+	extractor := extractorFunc(func(ctx context.Context, request instructor.Request, responseType any) (*instructor.Response, error) {
+		return &instructor.Response{Metadata: instructor.Metadata{Usage: instructor.Usage{TotalTokens: 60_100}}},
+			json.Unmarshal([]byte(`{"name": "Robby", "age": 22}`), responseType)
+	})
+
+	requests := personRequests(2)
+	for idx := range requests {
+		requests[idx].MaxTokens = 1
+	}
+
+	start := time.Now()
+	results := instructor.ExtractBatch[ConformancePerson](context.Background(), extractor, requests, instructor.BatchOptions{
+		Concurrency: 1,
+		RateLimit:   instructor.RateLimit{TokensPerMinute: 60_000},
+	})
+
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("batch took %s, want the token limit to delay the second request", elapsed)
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Error(r.Err)
+		}
+	}
+}
+
+func TestExtractBatchTokenLimitDefaultMaxTokens(t *testing.T) {
+	// failed requests keep their estimate, which reserves DefaultMaxTokens for
+	// the output, so the second request waits for the excess over the limit
+	extractor := extractorFunc(func(ctx context.Context, request instructor.Request, responseType any) (*instructor.Response, error) {
+		return nil, errors.New("unavailable")
+	})
+
+	limit := 2*instructor.DefaultMaxTokens - 40
+	start := time.Now()
+	instructor.ExtractBatch[ConformancePerson](context.Background(), extractor, personRequests(2), instructor.BatchOptions{
+		Concurrency: 1,
+		RateLimit:   instructor.RateLimit{TokensPerMinute: limit},
+	})
+
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("batch took %s, want the default max tokens to delay the second request", elapsed)
+	}
+}
@@ -0,0 +1,134 @@
+package instructor_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	srv := newOpenAIServer(t, `{"name": "Robby", "age": 22}`).Fail(http.StatusInternalServerError)
+
+	breaker := instructor.NewCircuitBreaker(2, 50*time.Millisecond)
+	client := instructor.FromOpenAI(openAIClient(srv), instructor.WithMode(instructor.ModeJSON), instructor.WithCircuitBreaker(breaker))
+
+	extract := func() error {
+		var person ConformancePerson
+		_, err := client.Extract(context.Background(), neutralRequest(openai.GPT4o), &person)
+		return err
+	}
+
+	for range 2 {
+		if err := extract(); err == nil {
+			t.Fatal("expected a provider error")
+		}
+	}
+	if state := breaker.State(instructor.ProviderOpenAI, openai.GPT4o); state != instructor.CircuitOpen {
+		t.Fatalf("state = %s after 2 failures, want open", state)
+	}
+
+	var openErr *instructor.CircuitOpenError
+	if err := extract(); !errors.As(err, &openErr) || openErr.Model != openai.GPT4o {
+		t.Fatalf("err = %v, want a CircuitOpenError", err)
+	}
+	if n := srv.Calls(); n != 2 {
+		t.Errorf("provider called %d times, want no call while open", n)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if state := breaker.State(instructor.ProviderOpenAI, openai.GPT4o); state != instructor.CircuitHalfOpen {
+		t.Fatalf("state = %s after the cooldown, want half-open", state)
+	}
+
+	srv.Fail(http.StatusOK)
+	if err := extract(); err != nil {
+		t.Fatal(err)
+	}
+
+	circuits := breaker.Status()
+	if len(circuits) != 1 || circuits[0].State != instructor.CircuitClosed || circuits[0].Failures != 0 {
+		t.Errorf("status = %+v, want closed after a successful probe", circuits)
+	}
+}
+
+func TestCircuitBreakerFailedProbe(t *testing.T) {
+	srv := newOpenAIServer(t, `{"name": "Robby", "age": 22}`).Fail(http.StatusBadGateway)
+
+	breaker := instructor.NewCircuitBreaker(1, 20*time.Millisecond)
+	client := instructor.FromOpenAI(openAIClient(srv), instructor.WithCircuitBreaker(breaker))
+
+	var person ConformancePerson
+	_, _ = client.Extract(context.Background(), neutralRequest(openai.GPT4o), &person)
+
+	time.Sleep(30 * time.Millisecond)
+	_, _ = client.Extract(context.Background(), neutralRequest(openai.GPT4o), &person)
+
+	if state := breaker.State(instructor.ProviderOpenAI, openai.GPT4o); state != instructor.CircuitOpen {
+		t.Errorf("state = %s after a failed probe, want open", state)
+	}
+}
+
+func TestCircuitBreakerClientErrors(t *testing.T) {
+	srv := newOpenAIServer(t, `{"name": "Robby", "age": 22}`).Fail(http.StatusBadRequest)
+
+	breaker := instructor.NewCircuitBreaker(1, time.Minute)
+	client := instructor.FromOpenAI(openAIClient(srv), instructor.WithCircuitBreaker(breaker))
+
+	for range 3 {
+		var person ConformancePerson
+		if _, err := client.Extract(context.Background(), neutralRequest(openai.GPT4o), &person); err == nil {
+			t.Fatal("expected a request error")
+		}
+	}
+	if state := breaker.State(instructor.ProviderOpenAI, openai.GPT4o); state != instructor.CircuitClosed {
+		t.Errorf("state = %s after request errors, want closed", state)
+	}
+}
+
+func TestCircuitBreakerTransportErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	breaker := instructor.NewCircuitBreaker(1, time.Minute)
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = srv.URL + "/v1"
+	client := instructor.FromOpenAI(openai.NewClientWithConfig(cfg), instructor.WithCircuitBreaker(breaker))
+
+	var person ConformancePerson
+	_, _ = client.Extract(context.Background(), neutralRequest(openai.GPT4o), &person)
+
+	if state := breaker.State(instructor.ProviderOpenAI, openai.GPT4o); state != instructor.CircuitOpen {
+		t.Errorf("state = %s after a connection error, want open", state)
+	}
+}
+
+func TestCircuitBreakerTimeouts(t *testing.T) {
+	srv := newOpenAIServer(t, `{"name": "Robby", "age": 22}`).Hang(2)
+
+	breaker := instructor.NewCircuitBreaker(1, time.Minute)
+	client := instructor.FromOpenAI(openAIClient(srv), instructor.WithCircuitBreaker(breaker))
+
+	// a caller giving up is not a failure of the provider
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	var person ConformancePerson
+	_, _ = client.Extract(ctx, neutralRequest(openai.GPT4o), &person)
+
+	if state := breaker.State(instructor.ProviderOpenAI, openai.GPT4o); state != instructor.CircuitClosed {
+		t.Fatalf("state = %s after a canceled call, want closed", state)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, _ = client.Extract(ctx, neutralRequest(openai.GPT4o), &person)
+
+	if state := breaker.State(instructor.ProviderOpenAI, openai.GPT4o); state != instructor.CircuitOpen {
+		t.Errorf("state = %s after a call timed out, want open", state)
+	}
+}
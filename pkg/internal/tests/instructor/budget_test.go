@@ -0,0 +1,127 @@
+package instructor_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestBudgetStopsRetries(t *testing.T) {
+	client := instructor.FromOpenAI(
+		newFakeOpenAI(t, `not json`),
+		instructor.WithMode(instructor.ModeJSON),
+		instructor.WithMaxRetries(10),
+	)
+
+	budget := instructor.NewBudget(20, 0)
+	ctx := instructor.WithBudget(context.Background(), budget)
+
+	var person Person
+	_, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:    openai.GPT4o,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Robby is 22 years old."}},
+	}, &person)
+
+	var budgetErr *instructor.BudgetExceededError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("expected *instructor.BudgetExceededError, got %v", err)
+	}
+	// each call spends 15 tokens, so the third attempt is refused
+	if budgetErr.Tokens != 30 {
+		t.Errorf("expected 30 tokens spent, got %d", budgetErr.Tokens)
+	}
+
+	var extractionErr *instructor.ExtractionError
+	if !errors.As(err, &extractionErr) {
+		t.Fatalf("expected *instructor.ExtractionError, got %T", err)
+	}
+	if extractionErr.Attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", extractionErr.Attempts)
+	}
+
+	_, err = client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{Model: openai.GPT4o}, &person)
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("expected new extractions to be refused, got %v", err)
+	}
+}
+
+func TestBudgetStopsContinuations(t *testing.T) {
+	srv := newOpenAIServer(t, `{"name": "Ro`, `bby", "ag`, `e": 22}`).Truncate()
+	client := instructor.FromOpenAI(openAIClient(srv),
+		instructor.WithMode(instructor.ModeJSONSchema),
+		instructor.WithTruncation(instructor.Truncation{}),
+	)
+
+	ctx := instructor.WithBudget(context.Background(), instructor.NewBudget(20, 0))
+
+	var person ConformancePerson
+	_, err := client.Extract(ctx, neutralRequest(openai.GPT4o), &person)
+
+	var budgetErr *instructor.BudgetExceededError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("expected *instructor.BudgetExceededError, got %v", err)
+	}
+	// the second call takes the spend to 30 tokens, so the third is not made
+	if srv.Calls() != 2 || budgetErr.Tokens != 30 {
+		t.Errorf("calls = %d, tokens = %d, want 2 calls of 30 tokens", srv.Calls(), budgetErr.Tokens)
+	}
+}
+
+func TestBudgetStreams(t *testing.T) {
+	client := instructor.FromOpenAI(
+		newFakeOpenAI(t, `{"items": [{"name": "Robby", "age": 22}]}`),
+		instructor.WithMode(instructor.ModeJSON),
+	)
+
+	budget := instructor.NewBudget(10, 0)
+	ctx := instructor.WithBudget(context.Background(), budget)
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT4o,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Robby is 22 years old."}},
+		Stream:   true,
+	}
+
+	stream, err := client.CreateChatCompletionStream(ctx, request, *new(Person))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range stream {
+	}
+
+	if tokens, _ := budget.Spent(); tokens != 15 {
+		t.Errorf("expected the stream to spend 15 tokens, got %d", tokens)
+	}
+
+	_, err = client.CreateChatCompletionStream(ctx, request, *new(Person))
+	var budgetErr *instructor.BudgetExceededError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("expected new streams to be refused, got %v", err)
+	}
+}
+
+func TestBudgetRefusesUnpricedModels(t *testing.T) {
+	srv := newOpenAIServer(t, `{"name": "Robby", "age": 22}`)
+	client := instructor.FromOpenAI(openAIClient(srv), instructor.WithMode(instructor.ModeJSON))
+
+	ctx := instructor.WithBudget(context.Background(), instructor.NewBudget(0, 5))
+
+	var person Person
+	_, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{Model: "my-fine-tune"}, &person)
+
+	var unpricedErr *instructor.UnpricedModelError
+	if !errors.As(err, &unpricedErr) {
+		t.Fatalf("expected *instructor.UnpricedModelError, got %v", err)
+	}
+	if unpricedErr.Model != "my-fine-tune" || srv.Calls() != 0 {
+		t.Errorf("model = %q, calls = %d, want my-fine-tune refused before any call", unpricedErr.Model, srv.Calls())
+	}
+
+	// a token limit alone does not need prices
+	ctx = instructor.WithBudget(context.Background(), instructor.NewBudget(100, 0))
+	if _, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{Model: "my-fine-tune"}, &person); err != nil {
+		t.Fatal(err)
+	}
+}
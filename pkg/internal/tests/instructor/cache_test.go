@@ -0,0 +1,140 @@
+package instructor_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	anthropic "github.com/liushuangls/go-anthropic/v2"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestCache(t *testing.T) {
+	disk, err := instructor.NewDiskCache(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, cache := range map[string]instructor.Cache{
+		"memory": instructor.NewMemoryCache(10, time.Hour),
+		"disk":   disk,
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := instructor.FromOpenAI(
+				newFakeOpenAI(t, `{"name": "Robby", "age": 22}`, `{"name": "Other", "age": 1}`),
+				instructor.WithMode(instructor.ModeJSON),
+				instructor.WithCache(cache),
+			)
+			request := openai.ChatCompletionRequest{
+				Model:    openai.GPT4o,
+				Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Robby is 22 years old."}},
+			}
+
+			for idx, wantHit := range []bool{false, true} {
+				var md instructor.Metadata
+				var person Person
+				resp, err := client.CreateChatCompletion(instructor.WithMetadata(context.Background(), &md), request, &person)
+				if err != nil {
+					t.Fatalf("call %d: unexpected error: %v", idx, err)
+				}
+				if md.CacheHit != wantHit {
+					t.Errorf("call %d: cache hit: got %v, want %v", idx, md.CacheHit, wantHit)
+				}
+				if person.Name != "Robby" || person.Age != 22 {
+					t.Errorf("call %d: got %+v", idx, person)
+				}
+				if resp.Usage.TotalTokens != 15 {
+					t.Errorf("call %d: native total tokens: got %d, want 15", idx, resp.Usage.TotalTokens)
+				}
+			}
+
+			var md instructor.Metadata
+			var person Person
+			request.Messages[0].Content = "Someone else."
+			if _, err := client.CreateChatCompletion(instructor.WithMetadata(context.Background(), &md), request, &person); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if md.CacheHit || person.Name != "Other" {
+				t.Errorf("different request: got cache hit %v, %+v", md.CacheHit, person)
+			}
+		})
+	}
+}
+
+func TestCacheMetadata(t *testing.T) {
+	srv := newAnthropicThinkingServer(t, `{"name": "Robby", "age": 22}`, false)
+	cache := instructor.NewMemoryCache(10, time.Hour)
+	newClient := func(opts ...instructor.Options) *instructor.InstructorAnthropic {
+		return instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")),
+			append([]instructor.Options{instructor.WithMode(instructor.ModeJSON), instructor.WithCache(cache)}, opts...)...)
+	}
+
+	request := anthropicJSONRequest()
+	request.MaxTokens = 2048
+	request.Thinking = &anthropic.Thinking{Type: anthropic.ThinkingTypeEnabled, BudgetTokens: 1024}
+
+	client := newClient(instructor.WithReasoning())
+	for idx, wantHit := range []bool{false, true} {
+		var person ConformancePerson
+		var md instructor.Metadata
+		if _, err := client.CreateMessages(instructor.WithMetadata(context.Background(), &md), request, &person); err != nil {
+			t.Fatal(err)
+		}
+		if md.CacheHit != wantHit || md.Reasoning != "Robby is the person." || md.Model != string(request.Model) {
+			t.Errorf("call %d: metadata = %+v, want the reasoning of the cached extraction", idx, md)
+		}
+	}
+
+	// options changing the result are part of the key
+	for _, opts := range [][]instructor.Options{
+		nil,
+		{instructor.WithReasoning(), instructor.WithMode(instructor.ModeJSONSchema)},
+		{instructor.WithReasoning(), instructor.WithTruncation(instructor.Truncation{Strategy: instructor.TruncationFail})},
+	} {
+		var person ConformancePerson
+		var md instructor.Metadata
+		if _, err := newClient(opts...).CreateMessages(instructor.WithMetadata(context.Background(), &md), request, &person); err != nil {
+			t.Fatal(err)
+		}
+		if md.CacheHit {
+			t.Errorf("options %d: got a cache hit of an extraction made with other options", len(opts))
+		}
+	}
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	ctx := context.Background()
+	cache := instructor.NewMemoryCache(2, 0)
+
+	_ = cache.Set(ctx, "a", []byte("a"))
+	_ = cache.Set(ctx, "b", []byte("b"))
+	if _, ok, _ := cache.Get(ctx, "a"); !ok {
+		t.Fatal("a: expected hit")
+	}
+	_ = cache.Set(ctx, "c", []byte("c"))
+
+	if _, ok, _ := cache.Get(ctx, "b"); ok {
+		t.Error("b: expected least recently used entry to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok, _ := cache.Get(ctx, key); !ok {
+			t.Errorf("%s: expected hit", key)
+		}
+	}
+}
+
+func TestDiskCacheTTL(t *testing.T) {
+	ctx := context.Background()
+	cache, err := instructor.NewDiskCache(t.TempDir(), time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = cache.Set(ctx, "a", []byte("a"))
+	time.Sleep(10 * time.Millisecond)
+
+	if _, ok, _ := cache.Get(ctx, "a"); ok {
+		t.Error("expected expired entry to miss")
+	}
+}
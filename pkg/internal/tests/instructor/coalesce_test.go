@@ -0,0 +1,124 @@
+package instructor_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type Family struct {
+	Name    string   `json:"name"    validate:"required"`
+	Members []string `json:"members"`
+}
+
+func TestCoalescing(t *testing.T) {
+	ok := newOpenAIServer(t, `{"name": "Smith", "members": ["Robby", "Lucy"]}`)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		ok.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = srv.URL + "/v1"
+	client := instructor.FromOpenAI(openai.NewClientWithConfig(cfg),
+		instructor.WithMode(instructor.ModeJSON),
+		instructor.WithValidation(),
+		instructor.WithCoalescing(),
+	)
+
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT4o,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "The Smiths are Robby and Lucy."}},
+	}
+
+	const callers = 5
+	families := make([]Family, callers)
+	metadata := make([]instructor.Metadata, callers)
+	errs := make([]error, callers)
+
+	var wg sync.WaitGroup
+	extract := func(n int) {
+		defer wg.Done()
+		ctx := instructor.WithMetadata(context.Background(), &metadata[n])
+		_, errs[n] = client.CreateChatCompletion(ctx, request, &families[n])
+	}
+
+	wg.Add(1)
+	go extract(0)
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	for n := 1; n < callers; n++ {
+		wg.Add(1)
+		go extract(n)
+	}
+	// let the others join the call in flight
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("provider called %d times, want 1", n)
+	}
+
+	coalesced := 0
+	for n := range callers {
+		if errs[n] != nil {
+			t.Fatalf("caller %d: %v", n, errs[n])
+		}
+		if families[n].Name != "Smith" || len(families[n].Members) != 2 {
+			t.Errorf("caller %d got %+v", n, families[n])
+		}
+		if metadata[n].Coalesced {
+			coalesced++
+		}
+	}
+	if coalesced != callers-1 {
+		t.Errorf("%d callers coalesced, want %d", coalesced, callers-1)
+	}
+
+	// every caller owns its copy
+	families[0].Members[0] = "changed"
+	for n := 1; n < callers; n++ {
+		if families[n].Members[0] != "Robby" {
+			t.Errorf("caller %d shares the result of caller 0", n)
+		}
+	}
+}
+
+func TestCoalescingDistinctRequests(t *testing.T) {
+	srv := newOpenAIServer(t, `{"name": "Smith", "members": []}`)
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = srv.URL + "/v1"
+	client := instructor.FromOpenAI(openai.NewClientWithConfig(cfg), instructor.WithMode(instructor.ModeJSON), instructor.WithCoalescing())
+
+	for _, content := range []string{"The Smiths.", "The Joneses."} {
+		var family Family
+		var md instructor.Metadata
+		request := openai.ChatCompletionRequest{
+			Model:    openai.GPT4o,
+			Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: content}},
+		}
+		if _, err := client.CreateChatCompletion(instructor.WithMetadata(context.Background(), &md), request, &family); err != nil {
+			t.Fatal(err)
+		}
+		if md.Coalesced {
+			t.Errorf("sequential extraction was coalesced")
+		}
+	}
+	if srv.Calls() != 2 {
+		t.Errorf("provider called %d times, want 2", srv.Calls())
+	}
+}
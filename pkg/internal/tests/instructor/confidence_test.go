@@ -0,0 +1,101 @@
+package instructor_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestConfidence(t *testing.T) {
+	tokens := []openai.LogProb{
+		{Token: `{"`}, {Token: "name"}, {Token: `":`},
+		{Token: ` "`}, {Token: "Rob", LogProb: math.Log(0.9)}, {Token: "by", LogProb: math.Log(0.5)}, {Token: `",`},
+		{Token: ` "`}, {Token: "hobbies"}, {Token: `":`}, {Token: ` ["`}, {Token: "chess", LogProb: math.Log(0.8)}, {Token: `"]`},
+		{Token: `,"`}, {Token: "age"}, {Token: `":`}, {Token: " "}, {Token: "22", LogProb: math.Log(0.6)}, {Token: "}"},
+	}
+	var content string
+	for _, token := range tokens {
+		content += token.Token
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &req)
+		if !req.LogProbs {
+			t.Error("logprobs were not requested")
+		}
+
+		writeJSON(w, openai.ChatCompletionResponse{
+			Model: req.Model,
+			Choices: []openai.ChatCompletionChoice{{
+				Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+				FinishReason: openai.FinishReasonStop,
+				LogProbs:     &openai.LogProbs{Content: tokens},
+			}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = srv.URL + "/v1"
+	client := instructor.FromOpenAI(openai.NewClientWithConfig(cfg), instructor.WithMode(instructor.ModeJSON), instructor.WithConfidence())
+
+	var profile Profile
+	var md instructor.Metadata
+	if _, err := client.Extract(instructor.WithMetadata(context.Background(), &md), neutralRequest(openai.GPT4o), &profile); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]float64{"$.name": 0.45, "$.hobbies[0]": 0.8, "$.age": 0.6}
+	if len(md.Confidence) != len(want) {
+		t.Errorf("confidence = %v", md.Confidence)
+	}
+	for path, confidence := range want {
+		if math.Abs(md.Confidence[path]-confidence) > 1e-9 {
+			t.Errorf("confidence of %s = %v, want %v", path, md.Confidence[path], confidence)
+		}
+	}
+
+	if low := md.LowConfidence(0.7); !slices.Equal(low, []string{"$.age", "$.name"}) {
+		t.Errorf("low confidence paths = %v", low)
+	}
+}
+
+func TestConfidenceUnsupported(t *testing.T) {
+	// tool call arguments have no log probabilities
+	srv := newOpenAIServer(t, `{"name": "Robby", "age": 22}`)
+	client := instructor.FromOpenAI(openAIClient(srv), instructor.WithMode(instructor.ModeToolCall), instructor.WithConfidence())
+
+	var person ConformancePerson
+	if _, err := client.Extract(context.Background(), neutralRequest(openai.GPT4o), &person); err != nil {
+		t.Fatal(err)
+	}
+	if logprobs := srv.Request(0)["logprobs"]; logprobs != nil {
+		t.Errorf("tool call request has logprobs = %v", logprobs)
+	}
+
+	// the log probabilities of a continuation would only cover its end
+	srv = newOpenAIServer(t, `{"name": "Ro`, `bby", "age": 22}`).Truncate()
+	client = instructor.FromOpenAI(openAIClient(srv),
+		instructor.WithMode(instructor.ModeJSONSchema),
+		instructor.WithTruncation(instructor.Truncation{}),
+		instructor.WithConfidence(),
+	)
+
+	var md instructor.Metadata
+	if _, err := client.Extract(instructor.WithMetadata(context.Background(), &md), neutralRequest(openai.GPT4o), &person); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Request(0)["logprobs"] != true || srv.Request(1)["logprobs"] != nil || md.Confidence != nil {
+		t.Errorf("continuation requested logprobs = %v, confidence = %v", srv.Request(1)["logprobs"], md.Confidence)
+	}
+}
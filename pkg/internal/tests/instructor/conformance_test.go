@@ -0,0 +1,326 @@
+package instructor_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	cohere "github.com/cohere-ai/cohere-go/v2"
+	cohereclient "github.com/cohere-ai/cohere-go/v2/client"
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	"github.com/liushuangls/go-anthropic/v2"
+	openai "github.com/sashabaranov/go-openai"
+	"google.golang.org/genai"
+)
+
+type ConformancePerson struct {
+	Name string `json:"name" validate:"required"`
+	Age  int    `json:"age"  validate:"gte=0,lte=130"`
+}
+
+// conformanceClient runs extractions through one provider client.
+type conformanceClient struct {
+	// extract returns the usage reported by the native response.
+	extract func(ctx context.Context, person *ConformancePerson) (instructor.Usage, error)
+	stream  func(ctx context.Context) (<-chan any, error)
+}
+
+type conformanceProvider struct {
+	provider    instructor.Provider
+	modes       []instructor.Mode
+	streamModes []instructor.Mode
+	// usage is what the fake server reports for every call
+	usage instructor.Usage
+	// wrap adapts outputs to modes that expect them wrapped in the type name
+	wrap  func(mode instructor.Mode, output string) string
+	start func(t *testing.T, outputs []string, opts ...instructor.Options) (*fakeServer, conformanceClient)
+}
+
+func wrapStrict(mode instructor.Mode, output string) string {
+	if mode == instructor.ModeJSONStrict {
+		return `{"ConformancePerson": ` + output + `}`
+	}
+	return output
+}
+
+var conformanceProviders = []conformanceProvider{
+	{
+		provider:    instructor.ProviderOpenAI,
+		modes:       []instructor.Mode{instructor.ModeToolCall, instructor.ModeToolCallStrict, instructor.ModeJSON, instructor.ModeJSONStrict, instructor.ModeJSONSchema},
+		streamModes: []instructor.Mode{instructor.ModeToolCall, instructor.ModeToolCallStrict, instructor.ModeJSON, instructor.ModeJSONSchema},
+		usage:       instructor.Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15, CachedInputTokens: 4, ReasoningTokens: 2},
+		wrap:        wrapStrict,
+		start: func(t *testing.T, outputs []string, opts ...instructor.Options) (*fakeServer, conformanceClient) {
+			srv := newOpenAIServer(t, outputs...)
+			cfg := openai.DefaultConfig("test")
+			cfg.BaseURL = srv.URL + "/v1"
+			client := instructor.FromOpenAI(openai.NewClientWithConfig(cfg), opts...)
+
+			request := openai.ChatCompletionRequest{
+				Model:    openai.GPT4o,
+				Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Robby is 22 years old."}},
+			}
+			return srv, conformanceClient{
+				extract: func(ctx context.Context, person *ConformancePerson) (instructor.Usage, error) {
+					resp, err := client.CreateChatCompletion(ctx, request, person)
+					usage := instructor.Usage{
+						InputTokens:  resp.Usage.PromptTokens,
+						OutputTokens: resp.Usage.CompletionTokens,
+						TotalTokens:  resp.Usage.TotalTokens,
+					}
+					if resp.Usage.PromptTokensDetails != nil {
+						usage.CachedInputTokens = resp.Usage.PromptTokensDetails.CachedTokens
+					}
+					if resp.Usage.CompletionTokensDetails != nil {
+						usage.ReasoningTokens = resp.Usage.CompletionTokensDetails.ReasoningTokens
+					}
+					return usage, err
+				},
+				stream: func(ctx context.Context) (<-chan any, error) {
+					req := request
+					req.Stream = true
+					return client.CreateChatCompletionStream(ctx, req, *new(ConformancePerson))
+				},
+			}
+		},
+	},
+	{
+		provider: instructor.ProviderAnthropic,
+		modes:    []instructor.Mode{instructor.ModeToolCall, instructor.ModeJSONSchema},
+		usage:    instructor.Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15, CachedInputTokens: 3, CacheCreationTokens: 1},
+		start: func(t *testing.T, outputs []string, opts ...instructor.Options) (*fakeServer, conformanceClient) {
+			srv := newAnthropicServer(t, outputs...)
+			client := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")), opts...)
+
+			request := anthropic.MessagesRequest{
+				Model:     anthropic.ModelClaude3Dot5SonnetLatest,
+				Messages:  []anthropic.Message{anthropic.NewUserTextMessage("Robby is 22 years old.")},
+				MaxTokens: 500,
+			}
+			return srv, conformanceClient{
+				extract: func(ctx context.Context, person *ConformancePerson) (instructor.Usage, error) {
+					resp, err := client.CreateMessages(ctx, request, person)
+					input := resp.Usage.InputTokens + resp.Usage.CacheReadInputTokens + resp.Usage.CacheCreationInputTokens
+					return instructor.Usage{
+						InputTokens:         input,
+						OutputTokens:        resp.Usage.OutputTokens,
+						TotalTokens:         input + resp.Usage.OutputTokens,
+						CachedInputTokens:   resp.Usage.CacheReadInputTokens,
+						CacheCreationTokens: resp.Usage.CacheCreationInputTokens,
+					}, err
+				},
+			}
+		},
+	},
+	{
+		provider:    instructor.ProviderGoogle,
+		modes:       []instructor.Mode{instructor.ModeToolCall, instructor.ModeToolCallStrict, instructor.ModeJSON, instructor.ModeJSONStrict, instructor.ModeJSONSchema},
+		streamModes: []instructor.Mode{instructor.ModeJSON, instructor.ModeJSONStrict, instructor.ModeJSONSchema},
+		usage:       instructor.Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15, CachedInputTokens: 4, ReasoningTokens: 2},
+		wrap:        wrapStrict,
+		start: func(t *testing.T, outputs []string, opts ...instructor.Options) (*fakeServer, conformanceClient) {
+			srv := newGeminiServer(t, outputs...)
+			genaiClient, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+				APIKey:      "test",
+				Backend:     genai.BackendGeminiAPI,
+				HTTPOptions: genai.HTTPOptions{BaseURL: srv.URL},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := instructor.FromGoogle(genaiClient, opts...)
+
+			request := instructor.GoogleRequest{
+				Model:    "gemini-2.0-flash",
+				Contents: []*genai.Content{genai.NewContentFromText("Robby is 22 years old.", genai.RoleUser)},
+			}
+			return srv, conformanceClient{
+				extract: func(ctx context.Context, person *ConformancePerson) (instructor.Usage, error) {
+					resp, err := client.CreateChatCompletion(ctx, request, person)
+					if resp.UsageMetadata == nil {
+						return instructor.Usage{}, err
+					}
+					md := resp.UsageMetadata
+					return instructor.Usage{
+						InputTokens:       int(md.PromptTokenCount),
+						OutputTokens:      int(md.CandidatesTokenCount + md.ThoughtsTokenCount),
+						TotalTokens:       int(md.TotalTokenCount),
+						CachedInputTokens: int(md.CachedContentTokenCount),
+						ReasoningTokens:   int(md.ThoughtsTokenCount),
+					}, err
+				},
+				stream: func(ctx context.Context) (<-chan any, error) {
+					stream, err := client.CreateChatCompletionStream(ctx, request, *new(ConformancePerson))
+					if err != nil {
+						return nil, err
+					}
+					// items are streamed as their JSON
+					items := make(chan any)
+					go func() {
+						defer close(items)
+						for s := range stream {
+							person := new(ConformancePerson)
+							if err := json.Unmarshal([]byte(s), person); err != nil {
+								t.Errorf("streamed item %q: %v", s, err)
+							}
+							items <- person
+						}
+					}()
+					return items, nil
+				},
+			}
+		},
+	},
+	{
+		provider:    instructor.ProviderCohere,
+		modes:       []instructor.Mode{instructor.ModeJSON},
+		streamModes: []instructor.Mode{instructor.ModeJSON},
+		usage:       instructor.Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+		start: func(t *testing.T, outputs []string, opts ...instructor.Options) (*fakeServer, conformanceClient) {
+			srv := newCohereServer(t, outputs...)
+			client := instructor.FromCohere(cohereclient.NewClient(
+				cohereclient.WithToken("test"),
+				cohereclient.WithBaseURL(srv.URL),
+				cohereclient.WithMaxAttempts(1),
+			), opts...)
+
+			return srv, conformanceClient{
+				extract: func(ctx context.Context, person *ConformancePerson) (instructor.Usage, error) {
+					request := &cohere.ChatRequest{Model: toPtr("command-r-plus"), Message: "Robby is 22 years old."}
+					resp, err := client.Chat(ctx, request, person)
+					if resp.Meta == nil || resp.Meta.Tokens == nil {
+						return instructor.Usage{}, err
+					}
+					input, output := int(*resp.Meta.Tokens.InputTokens), int(*resp.Meta.Tokens.OutputTokens)
+					if request.Preamble != nil {
+						t.Errorf("caller's request was modified: preamble %q", *request.Preamble)
+					}
+					return instructor.Usage{InputTokens: input, OutputTokens: output, TotalTokens: input + output}, err
+				},
+				stream: func(ctx context.Context) (<-chan any, error) {
+					request := &cohere.ChatStreamRequest{Model: toPtr("command-r-plus"), Message: "Robby is 22 years old."}
+					return client.ChatStream(ctx, request, *new(ConformancePerson))
+				},
+			}
+		},
+	},
+}
+
+func toPtr[T any](v T) *T {
+	return &v
+}
+
+func scale(u instructor.Usage, n int) instructor.Usage {
+	return instructor.Usage{
+		InputTokens:         u.InputTokens * n,
+		OutputTokens:        u.OutputTokens * n,
+		TotalTokens:         u.TotalTokens * n,
+		CachedInputTokens:   u.CachedInputTokens * n,
+		CacheCreationTokens: u.CacheCreationTokens * n,
+		ReasoningTokens:     u.ReasoningTokens * n,
+	}
+}
+
+func withoutAttempts(u instructor.Usage) instructor.Usage {
+	u.Attempts = nil
+	return u
+}
+
+func TestConformance(t *testing.T) {
+	const valid = `{"name": "Robby", "age": 22}`
+
+	cases := []struct {
+		name     string
+		outputs  []string
+		attempts int
+	}{
+		{name: "first attempt", outputs: []string{valid}, attempts: 1},
+		{name: "retry on decode error", outputs: []string{`{"name": 1}`, valid}, attempts: 2},
+		{name: "retry on validation error", outputs: []string{`{"name": "Robby", "age": 500}`, `{"name": "", "age": 22}`, valid}, attempts: 3},
+	}
+
+	for _, p := range conformanceProviders {
+		for _, mode := range p.modes {
+			for _, tc := range cases {
+				t.Run(p.provider+"/"+mode+"/"+tc.name, func(t *testing.T) {
+					outputs := make([]string, len(tc.outputs))
+					for idx, output := range tc.outputs {
+						outputs[idx] = output
+						if p.wrap != nil {
+							outputs[idx] = p.wrap(mode, output)
+						}
+					}
+
+					srv, client := p.start(t, outputs, instructor.WithMode(mode), instructor.WithValidation())
+
+					var md instructor.Metadata
+					var person ConformancePerson
+					native, err := client.extract(instructor.WithMetadata(context.Background(), &md), &person)
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+
+					if person != (ConformancePerson{Name: "Robby", Age: 22}) {
+						t.Errorf("got %+v", person)
+					}
+					if srv.Calls() != tc.attempts || md.Attempts != tc.attempts {
+						t.Errorf("attempts: got %d calls and %d in metadata, want %d", srv.Calls(), md.Attempts, tc.attempts)
+					}
+					if md.Provider != p.provider {
+						t.Errorf("provider: got %q, want %q", md.Provider, p.provider)
+					}
+
+					want := scale(p.usage, tc.attempts)
+					if got := withoutAttempts(md.Usage); !reflect.DeepEqual(got, want) {
+						t.Errorf("usage: got %+v, want %+v", got, want)
+					}
+					if !reflect.DeepEqual(native, want) {
+						t.Errorf("native usage: got %+v, want %+v", native, want)
+					}
+					if len(md.Usage.Attempts) != tc.attempts {
+						t.Errorf("usage attempts: got %d, want %d", len(md.Usage.Attempts), tc.attempts)
+					}
+				})
+			}
+		}
+
+		for _, mode := range p.streamModes {
+			t.Run(p.provider+"/"+mode+"/stream", func(t *testing.T) {
+				_, client := p.start(t, []string{`{"items": [{"name": "Robby", "age": 22}, {"name": "Lucy", "age": 25}]}`},
+					instructor.WithMode(mode), instructor.WithValidation())
+
+				stream, err := client.stream(context.Background())
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				var people []ConformancePerson
+				for item := range stream {
+					people = append(people, *item.(*ConformancePerson))
+				}
+				if len(people) != 2 || people[0].Name != "Robby" || people[1] != (ConformancePerson{Name: "Lucy", Age: 25}) {
+					t.Errorf("got %+v", people)
+				}
+			})
+		}
+	}
+}
+
+func TestConformanceProviderErrors(t *testing.T) {
+	for _, p := range conformanceProviders {
+		t.Run(p.provider, func(t *testing.T) {
+			srv, client := p.start(t, []string{`{}`}, instructor.WithMode(p.modes[0]))
+			srv.Close()
+
+			var md instructor.Metadata
+			var person ConformancePerson
+			if _, err := client.extract(instructor.WithMetadata(context.Background(), &md), &person); err == nil {
+				t.Fatal("expected an error")
+			}
+			if md.Attempts != 1 {
+				t.Errorf("provider errors are not retried: got %d attempts", md.Attempts)
+			}
+		})
+	}
+}
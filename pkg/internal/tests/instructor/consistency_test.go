@@ -0,0 +1,170 @@
+package instructor_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	anthropic "github.com/liushuangls/go-anthropic/v2"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type Profile struct {
+	Name    string   `json:"name"    validate:"required"`
+	Age     int      `json:"age"`
+	Hobbies []string `json:"hobbies"`
+}
+
+func TestSelfConsistencyChoices(t *testing.T) {
+	outputs := []string{
+		`{"name": "Robby", "age": 22, "hobbies": ["chess", "golf"]}`,
+		`{"name": "Robby", "age": 23, "hobbies": ["chess"]}`,
+		`{"name": "Robbie", "age": 22, "hobbies": ["chess", "tennis"]}`,
+		`{"age": "not a number"}`,
+	}
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var req openai.ChatCompletionRequest
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &req)
+		if req.N != 4 {
+			t.Errorf("n = %d, want 4", req.N)
+		}
+
+		resp := openai.ChatCompletionResponse{
+			Model: req.Model,
+			Usage: openai.Usage{PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30},
+		}
+		for k, output := range outputs {
+			resp.Choices = append(resp.Choices, openai.ChatCompletionChoice{
+				Index:        k,
+				Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: output},
+				FinishReason: openai.FinishReasonStop,
+			})
+		}
+		writeJSON(w, resp)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = srv.URL + "/v1"
+	client := instructor.FromOpenAI(openai.NewClientWithConfig(cfg),
+		instructor.WithMode(instructor.ModeJSON),
+		instructor.WithValidation(),
+		instructor.WithSelfConsistency(instructor.SelfConsistency{Samples: 4}),
+	)
+
+	var profile Profile
+	var md instructor.Metadata
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT4o,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Robby is 22 and plays chess."}},
+	}
+	resp, err := client.CreateChatCompletion(instructor.WithMetadata(context.Background(), &md), request, &profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 1 {
+		t.Errorf("provider called %d times, want 1", calls)
+	}
+	if profile.Name != "Robby" || profile.Age != 22 {
+		t.Errorf("got %+v, want the majority", profile)
+	}
+	if want := []string{"chess", "golf", "tennis"}; !slices.Equal(profile.Hobbies, want) {
+		t.Errorf("hobbies = %v, want the union %v", profile.Hobbies, want)
+	}
+
+	// the invalid sample is left out of the vote
+	if md.Samples != 3 {
+		t.Errorf("%d samples merged, want 3", md.Samples)
+	}
+	want := map[string]float64{"$.name": 2.0 / 3, "$.age": 2.0 / 3, "$.hobbies": 1.0 / 3}
+	for path, agreement := range want {
+		if md.Agreement[path] != agreement {
+			t.Errorf("agreement of %s = %v, want %v", path, md.Agreement[path], agreement)
+		}
+	}
+	if resp.Usage.TotalTokens != 30 || len(resp.Choices) != 4 {
+		t.Errorf("response has %d tokens and %d choices", resp.Usage.TotalTokens, len(resp.Choices))
+	}
+}
+
+func TestSelfConsistencyExtractions(t *testing.T) {
+	srv := newAnthropicServer(t,
+		`{"name": "Robby", "age": 22, "hobbies": ["chess", "golf"]}`,
+		`{"name": "Robby", "age": 22, "hobbies": ["golf", "chess", "tennis"]}`,
+		`{"name": "Robby", "age": 22, "hobbies": ["chess", "golf"]}`,
+	)
+
+	client := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")),
+		instructor.WithMode(instructor.ModeToolCall),
+		instructor.WithSelfConsistency(instructor.SelfConsistency{Samples: 3, Slices: instructor.SliceIntersection}),
+		instructor.WithCoalescing(),
+	)
+
+	var profile Profile
+	var md instructor.Metadata
+	resp, err := client.CreateMessages(instructor.WithMetadata(context.Background(), &md), anthropic.MessagesRequest{
+		Model:     anthropic.ModelClaude3Haiku20240307,
+		Messages:  []anthropic.Message{anthropic.NewUserTextMessage("Robby is 22 and plays chess.")},
+		MaxTokens: 500,
+	}, &profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// samples are not coalesced
+	if srv.Calls() != 3 {
+		t.Errorf("provider called %d times, want 3", srv.Calls())
+	}
+
+	slices.Sort(profile.Hobbies)
+	if profile.Name != "Robby" || !slices.Equal(profile.Hobbies, []string{"chess", "golf"}) {
+		t.Errorf("got %+v, want the intersection of the hobbies", profile)
+	}
+	if md.Samples != 3 || md.Attempts != 3 || md.Agreement["$.name"] != 1 || md.Agreement["$.hobbies"] != 2.0/3 {
+		t.Errorf("metadata = %+v", md)
+	}
+	if resp.Usage.OutputTokens != 15 {
+		t.Errorf("response output tokens = %d, want the 15 of every sample", resp.Usage.OutputTokens)
+	}
+}
+
+func TestSelfConsistencyValidation(t *testing.T) {
+	// the samples are extracted and validated concurrently, which go test -race
+	// checks
+	srv := newAnthropicServer(t,
+		`{"name": "Robby", "age": 22, "hobbies": ["chess"]}`,
+		`{"name": "", "age": 23, "hobbies": ["golf"]}`,
+		`{"name": "Robby", "age": 22, "hobbies": ["chess"]}`,
+	)
+
+	client := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")),
+		instructor.WithMode(instructor.ModeToolCall),
+		instructor.WithValidation(),
+		instructor.WithMaxRetries(0),
+		instructor.WithSelfConsistency(instructor.SelfConsistency{Samples: 3}),
+	)
+
+	var profile Profile
+	var md instructor.Metadata
+	_, err := client.CreateMessages(instructor.WithMetadata(context.Background(), &md), anthropic.MessagesRequest{
+		Model:     anthropic.ModelClaude3Haiku20240307,
+		Messages:  []anthropic.Message{anthropic.NewUserTextMessage("Robby is 22 and plays chess.")},
+		MaxTokens: 500,
+	}, &profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Name != "Robby" || profile.Age != 22 || md.Samples != 2 {
+		t.Errorf("got %+v from %d samples, want the invalid sample left out", profile, md.Samples)
+	}
+}
@@ -0,0 +1,118 @@
+package instructor_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestCostTracking(t *testing.T) {
+	client := instructor.FromOpenAI(
+		newFakeOpenAI(t, `not json`, `{"name": "Robby", "age": 22}`),
+		instructor.WithMode(instructor.ModeJSON),
+		instructor.WithPrices(instructor.PriceTable{
+			instructor.ProviderOpenAI: {"gpt-4o": {Input: 1_000, Output: 2_000}},
+		}),
+	)
+
+	global := instructor.NewCostTracker()
+	feature := instructor.NewCostTracker()
+	ctx := instructor.WithCostTracker(instructor.WithCostTracker(context.Background(), global), feature)
+
+	var md instructor.Metadata
+	var person Person
+	_, err := client.CreateChatCompletion(instructor.WithMetadata(ctx, &md), openai.ChatCompletionRequest{
+		Model:    "gpt-4o-2024-08-06",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Robby is 22 years old."}},
+	}, &person)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 2 attempts of 10 input tokens at $1000/M and 5 output tokens at $2000/M
+	want := 2 * (10*0.001 + 5*0.002)
+	if math.Abs(md.Cost.Total-want) > 1e-9 {
+		t.Errorf("metadata cost: got %v, want %v", md.Cost.Total, want)
+	}
+	if md.Attempts != 2 {
+		t.Errorf("metadata attempts: got %d, want 2", md.Attempts)
+	}
+	for name, tracker := range map[string]*instructor.CostTracker{"global": global, "feature": feature} {
+		if math.Abs(tracker.Total().Total-want) > 1e-9 {
+			t.Errorf("%s tracker cost: got %v, want %v", name, tracker.Total().Total, want)
+		}
+		if tracker.Calls() != 2 {
+			t.Errorf("%s tracker calls: got %d, want 2", name, tracker.Calls())
+		}
+	}
+
+	failing := instructor.FromOpenAI(
+		newFakeOpenAI(t, `not json`),
+		instructor.WithMode(instructor.ModeJSON),
+		instructor.WithMaxRetries(0),
+	)
+	_, err = failing.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:    openai.GPT4oMini,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Robby is 22 years old."}},
+	}, &person)
+
+	var extractionErr *instructor.ExtractionError
+	if !errors.As(err, &extractionErr) {
+		t.Fatalf("expected *instructor.ExtractionError, got %T", err)
+	}
+	if extractionErr.Cost.Total <= 0 {
+		t.Errorf("expected error to carry the cost of the failed attempt")
+	}
+}
+
+func TestCostTrackingStreams(t *testing.T) {
+	client := instructor.FromOpenAI(
+		newFakeOpenAI(t, `{"items": [{"name": "Robby", "age": 22}]}`),
+		instructor.WithMode(instructor.ModeJSON),
+		instructor.WithPrices(instructor.PriceTable{
+			instructor.ProviderOpenAI: {"gpt-4o": {Input: 1_000, Output: 2_000}},
+		}),
+	)
+
+	tracker := instructor.NewCostTracker()
+	stream, err := client.CreateChatCompletionStream(instructor.WithCostTracker(context.Background(), tracker), openai.ChatCompletionRequest{
+		Model:    openai.GPT4o,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Robby is 22 years old."}},
+		Stream:   true,
+	}, *new(Person))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range stream {
+	}
+
+	// the usage reported at the end of the stream
+	want := 10*0.001 + 5*0.002
+	if math.Abs(tracker.Total().Total-want) > 1e-9 || tracker.Usage().TotalTokens != 15 {
+		t.Errorf("tracker: got %v for %d tokens, want %v for 15", tracker.Total().Total, tracker.Usage().TotalTokens, want)
+	}
+}
+
+func TestCostUnpriced(t *testing.T) {
+	client := instructor.FromOpenAI(newFakeOpenAI(t, `{"name": "Robby", "age": 22}`), instructor.WithMode(instructor.ModeJSON))
+
+	var md instructor.Metadata
+	var person ConformancePerson
+	if _, err := client.Extract(instructor.WithMetadata(context.Background(), &md), neutralRequest("my-fine-tune"), &person); err != nil {
+		t.Fatal(err)
+	}
+	if !md.Cost.Unpriced || md.Cost.Total != 0 {
+		t.Errorf("cost = %+v, want an unpriced model flagged", md.Cost)
+	}
+
+	if _, err := client.Extract(instructor.WithMetadata(context.Background(), &md), neutralRequest(openai.GPT4o), &person); err != nil {
+		t.Fatal(err)
+	}
+	if md.Cost.Unpriced || md.Cost.Total == 0 {
+		t.Errorf("cost = %+v, want gpt-4o priced", md.Cost)
+	}
+}
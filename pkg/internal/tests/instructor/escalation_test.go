@@ -0,0 +1,129 @@
+package instructor_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	"github.com/liushuangls/go-anthropic/v2"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const invalidPerson = `{"name": "", "age": 22}`
+
+func TestEscalationModel(t *testing.T) {
+	mock := instructor.NewMock(
+		instructor.WithValidation(),
+		instructor.WithEscalation(instructor.Escalation{After: 2, Model: "strong"}),
+	).Return(invalidPerson, invalidPerson, `{"name": "Robby", "age": 22}`)
+
+	var person ConformancePerson
+	resp, err := mock.Extract(context.Background(), neutralRequest("cheap"), &person)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := mock.Calls()
+	models := []string{}
+	for _, call := range calls {
+		models = append(models, call.Request.(instructor.Request).Model)
+	}
+	if strings.Join(models, ",") != "cheap,cheap,strong" {
+		t.Errorf("models = %v, want the strong model after 2 failures", models)
+	}
+	if resp.Model != "strong" || resp.Attempts != 3 {
+		t.Errorf("metadata = %+v", resp.Metadata)
+	}
+
+	// the escalated attempt is told what failed
+	messages := calls[2].Request.(instructor.Request).Messages
+	reask := messages[len(messages)-2:]
+	if reask[0].Role != instructor.RoleAssistant || reask[0].Content != invalidPerson {
+		t.Errorf("reask output = %+v", reask[0])
+	}
+	if reask[1].Role != instructor.RoleUser || !strings.Contains(reask[1].Content, "Name") {
+		t.Errorf("reask prompt = %q, want the validation error", reask[1].Content)
+	}
+}
+
+func TestEscalationNativeRequest(t *testing.T) {
+	srv := newOpenAIServer(t, invalidPerson, `{"name": "Robby", "age": 22}`)
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = srv.URL + "/v1"
+	client := instructor.FromOpenAI(openai.NewClientWithConfig(cfg),
+		instructor.WithMode(instructor.ModeJSON),
+		instructor.WithValidation(),
+		instructor.WithEscalation(instructor.Escalation{After: 1, Model: openai.GPT4o}),
+	)
+
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT4oMini,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Robby is 22 years old."}},
+	}
+
+	var person ConformancePerson
+	resp, err := client.CreateChatCompletion(context.Background(), request, &person)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if person.Name != "Robby" || resp.Model != openai.GPT4o {
+		t.Errorf("person = %+v from %s", person, resp.Model)
+	}
+
+	escalated := srv.Request(1)
+	if escalated["model"] != openai.GPT4o {
+		t.Errorf("model = %v, want %s", escalated["model"], openai.GPT4o)
+	}
+	messages := escalated["messages"].([]any)
+	if role := messages[len(messages)-2].(map[string]any)["role"]; role != "assistant" {
+		t.Errorf("failed output was not carried forward: %v", messages)
+	}
+	if len(request.Messages) != 1 {
+		t.Errorf("caller's request was modified")
+	}
+}
+
+func TestEscalationProvider(t *testing.T) {
+	openaiSrv := newOpenAIServer(t, invalidPerson)
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = openaiSrv.URL + "/v1"
+
+	anthropicSrv := newAnthropicServer(t, `{"name": "Robby", "age": 22}`)
+	anthropicClient := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(anthropicSrv.URL+"/v1")),
+		instructor.WithMode(instructor.ModeToolCall))
+
+	client := instructor.FromOpenAI(openai.NewClientWithConfig(cfg),
+		instructor.WithMode(instructor.ModeJSON),
+		instructor.WithValidation(),
+		instructor.WithEscalation(instructor.Escalation{After: 2, Client: anthropicClient, Model: "claude-3-5-sonnet-latest"}),
+	)
+
+	var person ConformancePerson
+	resp, err := client.Extract(context.Background(), neutralRequest(openai.GPT4oMini), &person)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Provider != instructor.ProviderAnthropic || resp.Model != "claude-3-5-sonnet-latest" {
+		t.Errorf("answered by %s %s, want Anthropic", resp.Provider, resp.Model)
+	}
+	if openaiSrv.Calls() != 2 || anthropicSrv.Calls() != 1 {
+		t.Errorf("calls = %d OpenAI, %d Anthropic", openaiSrv.Calls(), anthropicSrv.Calls())
+	}
+	if resp.Attempts != 3 || len(resp.Usage.Attempts) != 3 {
+		t.Errorf("metadata = %+v", resp.Metadata)
+	}
+
+	// native requests cannot be converted to another provider, which is
+	// known before any attempt
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT4oMini,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Robby is 22 years old."}},
+	}
+	if _, err := client.CreateChatCompletion(context.Background(), request, &person); err == nil || !strings.Contains(err.Error(), "Extract") {
+		t.Errorf("err = %v, want an error asking for Extract", err)
+	}
+	if openaiSrv.Calls() != 2 {
+		t.Errorf("calls = %d OpenAI, want the native request refused", openaiSrv.Calls())
+	}
+}
@@ -0,0 +1,184 @@
+package instructor_test
+
+import (
+	"context"
+	"testing"
+
+	cohereclient "github.com/cohere-ai/cohere-go/v2/client"
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	"github.com/liushuangls/go-anthropic/v2"
+	openai "github.com/sashabaranov/go-openai"
+	"google.golang.org/genai"
+)
+
+func neutralRequest(model string) instructor.Request {
+	return instructor.Request{
+		Model:       model,
+		System:      "You extract people.",
+		Temperature: toPtr(float32(0.2)),
+		MaxTokens:   200,
+		Messages: []instructor.Message{
+			instructor.UserMessage("Who is this?"),
+			instructor.AssistantMessage("Give me a description."),
+			instructor.UserMessage("Robby is 22 years old."),
+		},
+	}
+}
+
+func TestExtract(t *testing.T) {
+	output := `{"name": "Robby", "age": 22}`
+
+	tests := []struct {
+		name  string
+		model string
+		start func(t *testing.T) (*fakeServer, instructor.Instructor)
+		// check inspects the native request sent to the provider
+		check func(t *testing.T, body map[string]any)
+	}{
+		{
+			name:  "OpenAI",
+			model: openai.GPT4o,
+			start: func(t *testing.T) (*fakeServer, instructor.Instructor) {
+				srv := newOpenAIServer(t, output)
+				cfg := openai.DefaultConfig("test")
+				cfg.BaseURL = srv.URL + "/v1"
+				return srv, instructor.FromOpenAI(openai.NewClientWithConfig(cfg), instructor.WithMode(instructor.ModeJSON))
+			},
+			check: func(t *testing.T, body map[string]any) {
+				// JSON mode puts its schema instructions first
+				messages := body["messages"].([]any)
+				system := messages[1].(map[string]any)
+				if system["role"] != "system" || system["content"] != "You extract people." {
+					t.Errorf("message = %v, want the system prompt", system)
+				}
+				if body["max_tokens"] != float64(200) {
+					t.Errorf("max_tokens = %v, want 200", body["max_tokens"])
+				}
+			},
+		},
+		{
+			name:  "Anthropic",
+			model: string(anthropic.ModelClaude3Dot5SonnetLatest),
+			start: func(t *testing.T) (*fakeServer, instructor.Instructor) {
+				srv := newAnthropicServer(t, output)
+				return srv, instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")), instructor.WithMode(instructor.ModeToolCall))
+			},
+			check: func(t *testing.T, body map[string]any) {
+				if body["system"] != "You extract people." {
+					t.Errorf("system = %v, want the system prompt", body["system"])
+				}
+				if n := len(body["messages"].([]any)); n != 3 {
+					t.Errorf("got %d messages, want 3", n)
+				}
+			},
+		},
+		{
+			name:  "Google",
+			model: "gemini-2.0-flash",
+			start: func(t *testing.T) (*fakeServer, instructor.Instructor) {
+				srv := newGeminiServer(t, output)
+				client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+					APIKey:      "test",
+					Backend:     genai.BackendGeminiAPI,
+					HTTPOptions: genai.HTTPOptions{BaseURL: srv.URL},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return srv, instructor.FromGoogle(client, instructor.WithMode(instructor.ModeJSON))
+			},
+			check: func(t *testing.T, body map[string]any) {
+				if _, ok := body["systemInstruction"]; !ok {
+					t.Errorf("systemInstruction is missing")
+				}
+				config, _ := body["generationConfig"].(map[string]any)
+				if config["maxOutputTokens"] != float64(200) {
+					t.Errorf("maxOutputTokens = %v, want 200", config["maxOutputTokens"])
+				}
+				contents := body["contents"].([]any)
+				if role := contents[2].(map[string]any)["role"]; role != "model" {
+					t.Errorf("assistant role = %v, want model", role)
+				}
+			},
+		},
+		{
+			name:  "Cohere",
+			model: "command-r-plus",
+			start: func(t *testing.T) (*fakeServer, instructor.Instructor) {
+				srv := newCohereServer(t, output)
+				return srv, instructor.FromCohere(cohereclient.NewClient(
+					cohereclient.WithToken("test"),
+					cohereclient.WithBaseURL(srv.URL),
+					cohereclient.WithMaxAttempts(1),
+				), instructor.WithMode(instructor.ModeJSON))
+			},
+			check: func(t *testing.T, body map[string]any) {
+				if body["message"] != "Robby is 22 years old." {
+					t.Errorf("message = %v, want the last user message", body["message"])
+				}
+				if n := len(body["chat_history"].([]any)); n != 2 {
+					t.Errorf("got %d history messages, want 2", n)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, client := tt.start(t)
+
+			var person ConformancePerson
+			resp, err := client.Extract(context.Background(), neutralRequest(tt.model), &person)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if person != (ConformancePerson{Name: "Robby", Age: 22}) {
+				t.Errorf("person = %+v", person)
+			}
+			if resp.Provider != client.Provider() || resp.Model != tt.model || resp.Attempts != 1 {
+				t.Errorf("metadata = %+v", resp.Metadata)
+			}
+			if resp.Raw == nil {
+				t.Errorf("missing native response")
+			}
+			tt.check(t, srv.Request(0))
+		})
+	}
+}
+
+func TestExtractUnsupportedImages(t *testing.T) {
+	image := instructor.ImageFromURL("https://example.com/receipt.png")
+	request := instructor.Request{
+		Model:    "model",
+		Messages: []instructor.Message{instructor.UserMessage("Extract the receipt.", image)},
+	}
+
+	clients := map[string]instructor.Instructor{
+		"Anthropic": instructor.FromAnthropic(anthropic.NewClient("test")),
+		"Cohere":    instructor.FromCohere(cohereclient.NewClient()),
+	}
+	for name, client := range clients {
+		var person ConformancePerson
+		if _, err := client.Extract(context.Background(), request, &person); err == nil {
+			t.Errorf("%s: expected an error for an image URL", name)
+		}
+	}
+}
+
+func TestMockExtract(t *testing.T) {
+	mock := instructor.NewMock().Return(`{"name": "Robby", "age": 22}`)
+
+	var person ConformancePerson
+	resp, err := mock.Extract(context.Background(), neutralRequest("mock-model"), &person)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Model != "mock-model" || person.Name != "Robby" {
+		t.Errorf("resp = %+v, person = %+v", resp.Metadata, person)
+	}
+	if req, ok := mock.Calls()[0].Request.(instructor.Request); !ok || req.System != "You extract people." {
+		t.Errorf("recorded request = %#v", mock.Calls()[0].Request)
+	}
+}
@@ -0,0 +1,309 @@
+package instructor_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeServer scripts the outputs of a fake provider API: the n-th call returns
+// the n-th output, repeating the last one once they are exhausted. Fail, Hang
+// and Truncate script failures on top of the outputs.
+type fakeServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	outputs  []string
+	requests []map[string]any
+
+	status   int
+	hang     int
+	truncate bool
+	canceled chan struct{}
+	cancel   sync.Once
+}
+
+// fakeHandler answers a call with output, cut at the output token limit when
+// truncated is set.
+type fakeHandler func(f *fakeServer, w http.ResponseWriter, r *http.Request, body map[string]any, output string, truncated bool)
+
+func newFakeServer(t *testing.T, outputs []string, handle fakeHandler) *fakeServer {
+	t.Helper()
+
+	f := &fakeServer{outputs: outputs, canceled: make(chan struct{})}
+	stop := make(chan struct{})
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the server notices the client going away once the body is read
+		b, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		_ = json.Unmarshal(b, &body)
+
+		f.mu.Lock()
+		f.requests = append(f.requests, body)
+		call := len(f.requests) - 1
+		output := f.outputs[min(call, len(f.outputs)-1)]
+		status, hang := f.status, call < f.hang
+		truncated := f.truncate && call < len(f.outputs)-1
+		f.mu.Unlock()
+
+		switch {
+		case hang:
+			select {
+			case <-r.Context().Done():
+				f.cancel.Do(func() { close(f.canceled) })
+			case <-stop:
+			}
+		case status != 0 && status != http.StatusOK:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"type": "error", "error": {"type": "api_error", "message": "failed"}}`))
+		default:
+			handle(f, w, r, body, output, truncated)
+		}
+	}))
+	t.Cleanup(f.Close)
+	// Close waits for hanging calls, which return once stop is closed
+	t.Cleanup(func() { close(stop) })
+
+	return f
+}
+
+// Fail fails the following calls with status, until it is http.StatusOK.
+func (f *fakeServer) Fail(status int) *fakeServer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status = status
+	return f
+}
+
+// Hang hangs the first n calls until the client cancels them.
+func (f *fakeServer) Hang(n int) *fakeServer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hang = n
+	return f
+}
+
+// Canceled is closed once a hanging call was canceled.
+func (f *fakeServer) Canceled() <-chan struct{} {
+	return f.canceled
+}
+
+// Truncate cuts every output but the last one at the output token limit.
+func (f *fakeServer) Truncate() *fakeServer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.truncate = true
+	return f
+}
+
+// Calls returns the number of requests served.
+func (f *fakeServer) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requests)
+}
+
+// Request returns the decoded body of the n-th request.
+func (f *fakeServer) Request(n int) map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[n]
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// chunks splits s into pieces at awkward positions, as streamed tokens would be.
+func chunks(s string) []string {
+	var pieces []string
+	for len(s) > 7 {
+		pieces = append(pieces, s[:7])
+		s = s[7:]
+	}
+	return append(pieces, s)
+}
+
+// firstTool returns the name of the first tool of an OpenAI or Anthropic request.
+func firstTool(body map[string]any) (string, bool) {
+	tools, _ := body["tools"].([]any)
+	if len(tools) == 0 {
+		return "", false
+	}
+	tool, _ := tools[0].(map[string]any)
+	if function, ok := tool["function"].(map[string]any); ok {
+		tool = function
+	}
+	name, _ := tool["name"].(string)
+	return name, true
+}
+
+func jsonObject(output string) any {
+	var v any
+	if err := json.Unmarshal([]byte(output), &v); err != nil {
+		panic(fmt.Sprintf("tool outputs must be JSON: %q", output))
+	}
+	return v
+}
+
+// newOpenAIServer fakes the OpenAI chat completions API. Every call uses 10
+// prompt tokens (4 cached) and 5 completion tokens (2 reasoning).
+func newOpenAIServer(t *testing.T, outputs ...string) *fakeServer {
+	return newFakeServer(t, outputs, func(f *fakeServer, w http.ResponseWriter, r *http.Request, body map[string]any, output string, truncated bool) {
+		usage := map[string]any{
+			"prompt_tokens":             10,
+			"completion_tokens":         5,
+			"total_tokens":              15,
+			"prompt_tokens_details":     map[string]any{"cached_tokens": 4},
+			"completion_tokens_details": map[string]any{"reasoning_tokens": 2},
+		}
+		tool, hasTools := firstTool(body)
+
+		if stream, _ := body["stream"].(bool); stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			for idx, piece := range chunks(output) {
+				delta := map[string]any{"content": piece}
+				if hasTools {
+					function := map[string]any{"arguments": piece}
+					if idx == 0 {
+						function["name"] = tool
+					}
+					delta = map[string]any{"tool_calls": []any{map[string]any{"index": 0, "type": "function", "function": function}}}
+				}
+				b, _ := json.Marshal(map[string]any{"choices": []any{map[string]any{"index": 0, "delta": delta}}})
+				fmt.Fprintf(w, "data: %s\n\n", b)
+			}
+			b, _ := json.Marshal(map[string]any{"choices": []any{}, "usage": usage})
+			fmt.Fprintf(w, "data: %s\n\ndata: [DONE]\n\n", b)
+			return
+		}
+
+		message := map[string]any{"role": "assistant", "content": output}
+		if hasTools {
+			message = map[string]any{"role": "assistant", "tool_calls": []any{map[string]any{
+				"id":       "call_1",
+				"type":     "function",
+				"function": map[string]any{"name": tool, "arguments": output},
+			}}}
+		}
+		reason := "stop"
+		if truncated {
+			reason = "length"
+		}
+		writeJSON(w, map[string]any{
+			"model":   body["model"],
+			"choices": []any{map[string]any{"index": 0, "message": message, "finish_reason": reason}},
+			"usage":   usage,
+		})
+	})
+}
+
+// newAnthropicServer fakes the Anthropic messages API. Every call uses 10 input
+// tokens (3 read from and 1 written to the cache) and 5 output tokens.
+func newAnthropicServer(t *testing.T, outputs ...string) *fakeServer {
+	return newFakeServer(t, outputs, func(f *fakeServer, w http.ResponseWriter, r *http.Request, body map[string]any, output string, truncated bool) {
+		content := []any{map[string]any{"type": "text", "text": output}}
+		if tool, ok := firstTool(body); ok {
+			content = []any{map[string]any{"type": "tool_use", "id": "toolu_1", "name": tool, "input": jsonObject(output)}}
+		}
+		reason := "end_turn"
+		if truncated {
+			reason = "max_tokens"
+		}
+		writeJSON(w, map[string]any{
+			"id":          "msg_1",
+			"type":        "message",
+			"role":        "assistant",
+			"model":       body["model"],
+			"content":     content,
+			"stop_reason": reason,
+			"usage": map[string]any{
+				"input_tokens":                6,
+				"output_tokens":               5,
+				"cache_read_input_tokens":     3,
+				"cache_creation_input_tokens": 1,
+			},
+		})
+	})
+}
+
+// newGeminiServer fakes the Gemini generateContent API. Every call uses 10
+// prompt tokens (4 cached) and 5 output tokens (2 thoughts).
+func newGeminiServer(t *testing.T, outputs ...string) *fakeServer {
+	return newFakeServer(t, outputs, func(f *fakeServer, w http.ResponseWriter, r *http.Request, body map[string]any, output string, truncated bool) {
+		usage := map[string]any{
+			"promptTokenCount":        10,
+			"cachedContentTokenCount": 4,
+			"candidatesTokenCount":    3,
+			"thoughtsTokenCount":      2,
+			"totalTokenCount":         15,
+		}
+		reason := "STOP"
+		if truncated {
+			reason = "MAX_TOKENS"
+		}
+		candidate := func(parts []any) map[string]any {
+			return map[string]any{"content": map[string]any{"role": "model", "parts": parts}, "finishReason": reason}
+		}
+
+		if strings.HasSuffix(r.URL.Path, ":streamGenerateContent") {
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, piece := range chunks(output) {
+				b, _ := json.Marshal(map[string]any{"candidates": []any{candidate([]any{map[string]any{"text": piece}})}})
+				fmt.Fprintf(w, "data: %s\r\n\r\n", b)
+			}
+			return
+		}
+
+		parts := []any{map[string]any{"text": output}}
+		if tools, ok := body["tools"].([]any); ok && len(tools) > 0 {
+			declarations := tools[0].(map[string]any)["functionDeclarations"].([]any)
+			name := declarations[0].(map[string]any)["name"]
+			parts = []any{map[string]any{"functionCall": map[string]any{"name": name, "args": jsonObject(output)}}}
+		}
+		writeJSON(w, map[string]any{
+			"candidates":    []any{candidate(parts)},
+			"usageMetadata": usage,
+		})
+	})
+}
+
+// newCohereServer fakes the Cohere v1 chat API. Every call uses 10 input
+// tokens and 5 output tokens.
+func newCohereServer(t *testing.T, outputs ...string) *fakeServer {
+	return newFakeServer(t, outputs, func(f *fakeServer, w http.ResponseWriter, r *http.Request, body map[string]any, output string, truncated bool) {
+		reason := "COMPLETE"
+		if truncated {
+			reason = "MAX_TOKENS"
+		}
+		response := map[string]any{
+			"text":          output,
+			"generation_id": "gen_1",
+			"finish_reason": reason,
+			"meta":          map[string]any{"tokens": map[string]any{"input_tokens": 10, "output_tokens": 5}},
+		}
+
+		if stream, _ := body["stream"].(bool); stream {
+			w.Header().Set("Content-Type", "application/stream+json")
+			events := []any{map[string]any{"event_type": "stream-start", "generation_id": "gen_1", "is_finished": false}}
+			for _, piece := range chunks(output) {
+				events = append(events, map[string]any{"event_type": "text-generation", "text": piece, "is_finished": false})
+			}
+			events = append(events, map[string]any{"event_type": "stream-end", "finish_reason": reason, "response": response, "is_finished": true})
+			for _, event := range events {
+				b, _ := json.Marshal(event)
+				fmt.Fprintf(w, "%s\n", b)
+			}
+			return
+		}
+
+		writeJSON(w, response)
+	})
+}
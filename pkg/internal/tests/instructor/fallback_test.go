@@ -0,0 +1,132 @@
+package instructor_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	"github.com/liushuangls/go-anthropic/v2"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestFallbackProviderError(t *testing.T) {
+	failing := instructor.NewMock().ReturnOutputs(instructor.MockOutput{
+		Err:   errors.New("503 service unavailable"),
+		Usage: instructor.Usage{InputTokens: 7, TotalTokens: 7},
+	})
+
+	srv := newAnthropicServer(t, `{"name": "Robby", "age": 22}`)
+	anthropicClient := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")))
+
+	chain := instructor.NewFallback(
+		instructor.FallbackEntry{Client: failing},
+		instructor.FallbackEntry{Client: anthropicClient, Model: "claude-3-5-sonnet-latest", Mode: instructor.ModeToolCall},
+	)
+
+	var md instructor.Metadata
+	var person ConformancePerson
+	resp, err := chain.Extract(instructor.WithMetadata(context.Background(), &md), neutralRequest("gpt-4o"), &person)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if person != (ConformancePerson{Name: "Robby", Age: 22}) {
+		t.Errorf("person = %+v", person)
+	}
+	if resp.Provider != instructor.ProviderAnthropic || resp.Model != "claude-3-5-sonnet-latest" {
+		t.Errorf("answered by %s %s, want Anthropic", resp.Provider, resp.Model)
+	}
+	if resp.Attempts != 2 || resp.Usage.InputTokens != 17 || len(resp.Usage.Attempts) != 2 {
+		t.Errorf("usage = %+v over %d attempts, want both clients combined", resp.Usage, resp.Attempts)
+	}
+	if md.Provider != resp.Provider || md.Attempts != resp.Attempts {
+		t.Errorf("context metadata = %+v", md)
+	}
+	if _, ok := firstTool(srv.Request(0)); !ok {
+		t.Errorf("mode override was not applied")
+	}
+	if failing.Calls()[0].Request.(instructor.Request).Model != "gpt-4o" {
+		t.Errorf("request model was not kept for an entry without a model")
+	}
+}
+
+func TestFallbackExhaustedRetries(t *testing.T) {
+	invalid := instructor.NewMock(instructor.WithValidation(), instructor.WithMaxRetries(1)).
+		Return(`{"name": "", "age": 22}`, `{"name": "", "age": 22}`)
+	valid := instructor.NewMock(instructor.WithValidation()).
+		Return(`{"age": 23, "name": "Robby"}`)
+
+	chain := instructor.NewFallback(instructor.FallbackEntry{Client: invalid}, instructor.FallbackEntry{Client: valid})
+
+	var person ConformancePerson
+	resp, err := chain.Extract(context.Background(), neutralRequest("model"), &person)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if person.Age != 23 || resp.Attempts != 3 {
+		t.Errorf("person = %+v after %d attempts", person, resp.Attempts)
+	}
+}
+
+func TestFallbackModeOverride(t *testing.T) {
+	mock := instructor.NewMock().Return(`{"name": "Robby", "age": 22}`)
+	chain := instructor.NewFallback(instructor.FallbackEntry{Client: mock, Mode: instructor.ModeToolCall})
+
+	var person ConformancePerson
+	if _, err := chain.Extract(context.Background(), neutralRequest("model"), &person); err == nil || len(mock.Calls()) != 0 {
+		t.Errorf("err = %v after %d calls, want the mode override of a mock rejected", err, len(mock.Calls()))
+	}
+}
+
+func TestFallbackTimeout(t *testing.T) {
+	stop := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-stop:
+		}
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() { close(stop) })
+
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = slow.URL + "/v1"
+	openaiClient := instructor.FromOpenAI(openai.NewClientWithConfig(cfg))
+
+	chain := instructor.NewFallback(
+		instructor.FallbackEntry{Client: openaiClient, Timeout: 20 * time.Millisecond},
+		instructor.FallbackEntry{Client: instructor.NewMock().Return(`{"name": "Robby", "age": 22}`)},
+	)
+
+	var person ConformancePerson
+	resp, err := chain.Extract(context.Background(), neutralRequest("gpt-4o"), &person)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Provider != instructor.ProviderMock {
+		t.Errorf("answered by %s, want Mock", resp.Provider)
+	}
+}
+
+func TestFallbackAllFail(t *testing.T) {
+	chain := instructor.NewFallback(
+		instructor.FallbackEntry{Client: instructor.NewMock().Return(`not json`)},
+		instructor.FallbackEntry{Client: instructor.NewMock()},
+	)
+
+	var person ConformancePerson
+	_, err := chain.Extract(context.Background(), neutralRequest("model"), &person)
+
+	var fallbackErr *instructor.FallbackError
+	if !errors.As(err, &fallbackErr) || len(fallbackErr.Failures) != 2 {
+		t.Fatalf("err = %v, want a FallbackError for both clients", err)
+	}
+	var extractionErr *instructor.ExtractionError
+	if !errors.As(err, &extractionErr) {
+		t.Errorf("client errors are not unwrapped")
+	}
+}
@@ -0,0 +1,195 @@
+package instructor_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	anthropic "github.com/liushuangls/go-anthropic/v2"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestHedging(t *testing.T) {
+	srv := newOpenAIServer(t, `{"name": "Robby", "age": 22}`).Hang(1)
+	client := instructor.FromOpenAI(openAIClient(srv),
+		instructor.WithMode(instructor.ModeJSON),
+		instructor.WithHedging(instructor.Hedge{Delay: 20 * time.Millisecond}),
+	)
+
+	var person ConformancePerson
+	var md instructor.Metadata
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT4o,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Robby is 22 years old."}},
+	}
+	resp, err := client.CreateChatCompletion(instructor.WithMetadata(context.Background(), &md), request, &person)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if person.Name != "Robby" || person.Age != 22 {
+		t.Errorf("got %+v", person)
+	}
+	if n := srv.Calls(); n != 2 {
+		t.Errorf("provider called %d times, want 2", n)
+	}
+	select {
+	case <-srv.Canceled():
+	case <-time.After(time.Second):
+		t.Error("the slow extraction was not canceled")
+	}
+
+	// the winner returns without waiting for the canceled attempt
+	if md.Attempts != 1 || md.Provider != instructor.ProviderOpenAI {
+		t.Errorf("metadata = %+v, want 1 OpenAI attempt", md)
+	}
+	if md.Usage.TotalTokens != 15 || resp.Usage.TotalTokens != 15 {
+		t.Errorf("usage = %d, response usage = %d, want the 15 tokens of the completed call", md.Usage.TotalTokens, resp.Usage.TotalTokens)
+	}
+}
+
+func TestHedgingOtherProvider(t *testing.T) {
+	srv := newOpenAIServer(t, `{"name": "Robby", "age": 22}`).Hang(1)
+	anthropicSrv := newAnthropicServer(t, `{"name": "Robby", "age": 22}`)
+
+	anthropicClient := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(anthropicSrv.URL+"/v1")),
+		instructor.WithMode(instructor.ModeToolCall),
+	)
+
+	client := instructor.FromOpenAI(openAIClient(srv),
+		instructor.WithMode(instructor.ModeJSON),
+		instructor.WithHedging(instructor.Hedge{Delay: 20 * time.Millisecond, Client: anthropicClient, Model: "claude-3-5-haiku-latest"}),
+	)
+
+	var person ConformancePerson
+	resp, err := client.Extract(context.Background(), neutralRequest(openai.GPT4o), &person)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if person.Name != "Robby" {
+		t.Errorf("got %+v", person)
+	}
+	if resp.Provider != instructor.ProviderAnthropic || resp.Model != "claude-3-5-haiku-latest" {
+		t.Errorf("answered by %s %s, want the hedged client", resp.Provider, resp.Model)
+	}
+	if model, _ := anthropicSrv.Request(0)["model"].(string); model != "claude-3-5-haiku-latest" {
+		t.Errorf("hedged request model = %q", model)
+	}
+	<-srv.Canceled()
+
+	// native requests cannot be hedged with another provider
+	if _, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{Model: openai.GPT4o}, &person); err == nil {
+		t.Error("expected an error hedging a native request with another provider")
+	}
+}
+
+func TestHedgingEarlyFailure(t *testing.T) {
+	srv := newOpenAIServer(t, `{"name": "Robby", "age": 22}`).Fail(http.StatusBadRequest)
+	anthropicSrv := newAnthropicServer(t, `{"name": "Robby", "age": 22}`)
+
+	anthropicClient := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(anthropicSrv.URL+"/v1")),
+		instructor.WithMode(instructor.ModeToolCall),
+	)
+	client := instructor.FromOpenAI(openAIClient(srv),
+		instructor.WithMode(instructor.ModeJSON),
+		instructor.WithHedging(instructor.Hedge{Delay: time.Minute, Client: anthropicClient, Model: "claude-3-5-haiku-latest"}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var person ConformancePerson
+	resp, err := client.Extract(ctx, neutralRequest(openai.GPT4o), &person)
+	if err != nil {
+		t.Fatalf("err = %v, want the hedged extraction launched when the first one failed", err)
+	}
+	if person.Name != "Robby" || resp.Provider != instructor.ProviderAnthropic {
+		t.Errorf("got %+v from %s", person, resp.Provider)
+	}
+}
+
+func TestHedgingCoalescing(t *testing.T) {
+	srv := newOpenAIServer(t, `{"name": "Robby", "age": 22}`).Hang(1)
+	client := instructor.FromOpenAI(openAIClient(srv),
+		instructor.WithMode(instructor.ModeJSON),
+		instructor.WithCoalescing(),
+		instructor.WithHedging(instructor.Hedge{Delay: 20 * time.Millisecond}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// the hedged extraction must not wait on the flight of the one it races
+	var person ConformancePerson
+	if _, err := client.Extract(ctx, neutralRequest(openai.GPT4o), &person); err != nil {
+		t.Fatal(err)
+	}
+	if person.Name != "Robby" || srv.Calls() != 2 {
+		t.Errorf("person = %+v, calls = %d, want 2", person, srv.Calls())
+	}
+}
+
+type hedgeHookKey struct{}
+
+// countingHooks counts hook calls, and attempts outside of an extraction.
+type countingHooks struct {
+	mu                             sync.Mutex
+	extractions, attempts, orphans int
+	ended                          chan struct{}
+}
+
+func (h *countingHooks) ExtractionStart(ctx context.Context, info instructor.ExtractionInfo) context.Context {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.extractions++
+	return context.WithValue(ctx, hedgeHookKey{}, true)
+}
+
+func (h *countingHooks) AttemptStart(ctx context.Context, info instructor.ExtractionInfo, attempt int) context.Context {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.attempts++
+	if ctx.Value(hedgeHookKey{}) == nil {
+		h.orphans++
+	}
+	return ctx
+}
+
+func (h *countingHooks) AttemptEnd(ctx context.Context, info instructor.ExtractionInfo, result instructor.AttemptResult) {
+	h.ended <- struct{}{}
+}
+
+func (h *countingHooks) ExtractionEnd(ctx context.Context, info instructor.ExtractionInfo, result instructor.ExtractionResult) {
+}
+
+func TestHedgingHooks(t *testing.T) {
+	srv := newOpenAIServer(t, `{"name": "Robby", "age": 22}`).Hang(1)
+	hooks := &countingHooks{ended: make(chan struct{}, 2)}
+	client := instructor.FromOpenAI(openAIClient(srv),
+		instructor.WithMode(instructor.ModeJSON),
+		instructor.WithHedging(instructor.Hedge{Delay: 20 * time.Millisecond}),
+		instructor.WithHooks(hooks),
+	)
+
+	var person ConformancePerson
+	if _, err := client.Extract(context.Background(), neutralRequest(openai.GPT4o), &person); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		select {
+		case <-hooks.ended:
+		case <-time.After(time.Second):
+			t.Fatal("an attempt did not end")
+		}
+	}
+
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	if hooks.extractions != 1 || hooks.attempts != 2 || hooks.orphans != 0 {
+		t.Errorf("extractions = %d, attempts = %d (%d orphans), want 1 extraction of both attempts", hooks.extractions, hooks.attempts, hooks.orphans)
+	}
+}
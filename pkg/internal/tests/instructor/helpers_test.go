@@ -0,0 +1,19 @@
+package instructor_test
+
+import (
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// newFakeOpenAI returns a client of newOpenAIServer.
+func newFakeOpenAI(t *testing.T, outputs ...string) *openai.Client {
+	return openAIClient(newOpenAIServer(t, outputs...))
+}
+
+// openAIClient returns a client of the fake OpenAI API srv.
+func openAIClient(srv *fakeServer) *openai.Client {
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = srv.URL + "/v1"
+	return openai.NewClientWithConfig(cfg)
+}
@@ -0,0 +1,106 @@
+package instructor_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	anthropic "github.com/liushuangls/go-anthropic/v2"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type Account struct {
+	Owner    string `json:"owner"`
+	Password string `json:"password" instructor:"sensitive"`
+}
+
+func TestLoggerRedaction(t *testing.T) {
+	tests := []struct {
+		name      string
+		redaction instructor.Redaction
+		want      []string
+		wantNot   []string
+	}{
+		{
+			name:      "default redacts sensitive fields",
+			redaction: instructor.DefaultRedaction,
+			want:      []string{"what is the password", `\"owner\":\"robby\"`, `\"password\":\"[REDACTED]\"`},
+			wantNot:   []string{"hunter2"},
+		},
+		{
+			name:      "messages also redacts sensitive fields",
+			redaction: instructor.RedactMessages,
+			want:      []string{"content=[REDACTED]", `\"password\":\"[REDACTED]\"`},
+			wantNot:   []string{"what is the password", "hunter2"},
+		},
+		{
+			name:      "none",
+			redaction: instructor.RedactNone,
+			want:      []string{"what is the password", "hunter2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+			client := instructor.FromOpenAI(
+				newFakeOpenAI(t, `{"owner": "robby", "password": "hunter2"}`),
+				instructor.WithMode(instructor.ModeJSON),
+				instructor.WithLogger(logger),
+				instructor.WithRedaction(tt.redaction),
+			)
+
+			var account Account
+			_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+				Model:    openai.GPT4o,
+				Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "what is the password"}},
+			}, &account)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			logs := buf.String()
+			for _, s := range tt.want {
+				if !strings.Contains(logs, s) {
+					t.Errorf("expected logs to contain %q:\n%s", s, logs)
+				}
+			}
+			for _, s := range tt.wantNot {
+				if strings.Contains(logs, s) {
+					t.Errorf("expected logs not to contain %q:\n%s", s, logs)
+				}
+			}
+		})
+	}
+}
+
+func TestLoggerAnthropicSystemParts(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	srv := newAnthropicServer(t, `{"name": "Robby", "age": 22}`)
+	client := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")),
+		instructor.WithMode(instructor.ModeToolCall),
+		instructor.WithLogger(logger),
+		instructor.WithRedaction(instructor.RedactNone),
+	)
+
+	// the system parts are sent instead of the system string they were made of
+	request := anthropicJSONRequest()
+	request.System = "Extract the people."
+	request.MultiSystem = anthropic.NewMultiSystemMessages(request.System)
+
+	var person Person
+	if _, err := client.CreateMessages(context.Background(), request, &person); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(buf.String(), "Extract the people."); n != 1 {
+		t.Errorf("system prompt logged %d times, want once:\n%s", n, buf.String())
+	}
+}
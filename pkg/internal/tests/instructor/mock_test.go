@@ -0,0 +1,86 @@
+package instructor_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+)
+
+type ValidatedPerson struct {
+	Name string `json:"name" validate:"required"`
+	Age  int    `json:"age"  validate:"gte=0,lte=130"`
+}
+
+func TestMockRetries(t *testing.T) {
+	mock := instructor.NewMock(instructor.WithValidation()).ReturnOutputs(
+		instructor.MockOutput{Text: `{"name": "Robby", "age":`, Usage: instructor.Usage{InputTokens: 1, OutputTokens: 1, TotalTokens: 2}},
+		instructor.MockOutput{Text: `{"name": "Robby", "age": 222}`, Usage: instructor.Usage{InputTokens: 1, OutputTokens: 1, TotalTokens: 2}},
+		instructor.MockOutput{Text: `Sure: {"name": "Robby", "age": 22}`, Usage: instructor.Usage{InputTokens: 1, OutputTokens: 1, TotalTokens: 2}},
+	)
+
+	var person ValidatedPerson
+	resp, err := mock.Chat(context.Background(), "Robby is 22 years old.", &person)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if person != (ValidatedPerson{Name: "Robby", Age: 22}) {
+		t.Errorf("got %+v", person)
+	}
+	if resp.Usage.TotalTokens != 6 {
+		t.Errorf("total tokens: got %d, want 6", resp.Usage.TotalTokens)
+	}
+
+	calls := mock.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("calls: got %d, want 3", len(calls))
+	}
+	for _, call := range calls {
+		if call.Request != "Robby is 22 years old." || call.Schema.Name() != "ValidatedPerson" {
+			t.Errorf("got request %v with schema %q", call.Request, call.Schema.Name())
+		}
+	}
+}
+
+func TestMockErrors(t *testing.T) {
+	providerErr := errors.New("overloaded")
+	mock := instructor.NewMock().ReturnOutputs(instructor.MockOutput{Err: providerErr})
+
+	var person Person
+	if _, err := mock.Chat(context.Background(), "", &person); !errors.Is(err, providerErr) {
+		t.Errorf("provider error: got %v", err)
+	}
+
+	mock = instructor.NewMock(instructor.WithMaxRetries(1)).Return(`not json`, `still not json`)
+	_, err := mock.Chat(context.Background(), "", &person)
+
+	var extractionErr *instructor.ExtractionError
+	if !errors.As(err, &extractionErr) || extractionErr.Attempts != 2 {
+		t.Errorf("exhausted retries: got %v", err)
+	}
+	if mock.Remaining() != 0 {
+		t.Errorf("remaining: got %d, want 0", mock.Remaining())
+	}
+}
+
+func TestMockStream(t *testing.T) {
+	mock := instructor.NewMock().ReturnStream(`{"items": [{"name": "Robby", "age": 22},`, ` {"name": "Lucy"`, `, "age": 25}]}`)
+
+	stream, err := mock.ChatStream(context.Background(), "", *new(Person))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for item := range stream {
+		names = append(names, item.(*Person).Name)
+	}
+	if len(names) != 2 || names[0] != "Robby" || names[1] != "Lucy" {
+		t.Errorf("got %v", names)
+	}
+	if calls := mock.Calls(); len(calls) != 1 || !calls[0].Stream {
+		t.Errorf("got calls %+v", calls)
+	}
+}
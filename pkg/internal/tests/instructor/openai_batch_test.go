@@ -0,0 +1,256 @@
+package instructor_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// fakeOpenAIBatches fakes the Files and Batches endpoints of the OpenAI API,
+// answering the batch input lines with the outputs or failure statuses keyed
+// by custom ID, and the chat completions endpoint with a valid person.
+type fakeOpenAIBatches struct {
+	*httptest.Server
+	chat *fakeServer
+
+	mu       sync.Mutex
+	input    []openai.BatchChatCompletionRequest
+	polls    int
+	outputs  map[string]string
+	failures map[string]int
+}
+
+func newFakeOpenAIBatches(t *testing.T, outputs map[string]string, failures map[string]int) *fakeOpenAIBatches {
+	f := &fakeOpenAIBatches{outputs: outputs, failures: failures, chat: newOpenAIServer(t, `{"name": "Retried", "age": 30}`)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/files", func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("reading the uploaded file: %v", err)
+			return
+		}
+		f.mu.Lock()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var line openai.BatchChatCompletionRequest
+			_ = json.Unmarshal(scanner.Bytes(), &line)
+			f.input = append(f.input, line)
+		}
+		f.mu.Unlock()
+		writeJSON(w, openai.File{ID: "file-in", Purpose: r.FormValue("purpose")})
+	})
+	mux.HandleFunc("POST /v1/batches", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, openai.Batch{ID: "batch_1", Status: "validating"})
+	})
+	mux.HandleFunc("GET /v1/batches/batch_1", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.polls++
+		if f.polls < 2 {
+			writeJSON(w, openai.Batch{ID: "batch_1", Status: "in_progress"})
+			return
+		}
+		out, errs := "file-out", "file-err"
+		writeJSON(w, openai.Batch{ID: "batch_1", Status: "completed", OutputFileID: &out, ErrorFileID: &errs})
+	})
+	mux.HandleFunc("GET /v1/files/{id}/content", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for _, line := range f.input {
+			status, failed := f.failures[line.CustomID]
+			output, ok := f.outputs[line.CustomID]
+			if (r.PathValue("id") == "file-err") != failed || (!failed && !ok) {
+				continue
+			}
+
+			var body any = map[string]any{"error": map[string]any{"message": "server error", "type": "server_error"}}
+			if !failed {
+				body = openai.ChatCompletionResponse{
+					Model:   line.Body.Model,
+					Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: output}}},
+					Usage:   openai.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+				}
+				status = http.StatusOK
+			}
+			b, _ := json.Marshal(map[string]any{
+				"id":        "batch_req_" + line.CustomID,
+				"custom_id": line.CustomID,
+				"response":  map[string]any{"status_code": status, "body": body},
+			})
+			fmt.Fprintf(w, "%s\n", b)
+		}
+	})
+	mux.Handle("POST /v1/chat/completions", f.chat.Config.Handler)
+
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// Input returns the lines of the uploaded batch input file.
+func (f *fakeOpenAIBatches) Input() []openai.BatchChatCompletionRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.input
+}
+
+func TestOpenAIBatch(t *testing.T) {
+	srv := newFakeOpenAIBatches(t,
+		map[string]string{
+			"robby": `{"name": "Robby", "age": 22}`,
+			"lucy":  `{"name": "", "age": 31}`,
+		},
+		map[string]int{"tom": http.StatusInternalServerError},
+	)
+
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = srv.URL + "/v1"
+	client := instructor.FromOpenAI(openai.NewClientWithConfig(cfg), instructor.WithMode(instructor.ModeJSON), instructor.WithValidation())
+
+	var requests []instructor.OpenAIBatchRequest
+	for _, id := range []string{"robby", "lucy", "tom", "anna"} {
+		requests = append(requests, instructor.OpenAIBatchRequest{
+			CustomID: id,
+			Request: openai.ChatCompletionRequest{
+				Model:    openai.GPT4oMini,
+				Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Who is " + id + "?"}},
+			},
+		})
+	}
+
+	ctx := context.Background()
+	batch, err := instructor.SubmitOpenAIBatch[ConformancePerson](ctx, client, requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := srv.Input()
+	if len(input) != 4 {
+		t.Fatalf("uploaded %d lines, want 4", len(input))
+	}
+	line := input[0]
+	if line.URL != openai.BatchEndpointChatCompletions || line.Body.ResponseFormat == nil || line.Body.Messages[0].Role != openai.ChatMessageRoleSystem {
+		t.Errorf("batch line is missing the JSON instructions: %+v", line)
+	}
+
+	batch, err = instructor.WaitOpenAIBatch(ctx, client, batch.ID, time.Millisecond)
+	if err != nil || batch.Status != "completed" {
+		t.Fatalf("batch = %+v, err = %v", batch, err)
+	}
+
+	results, err := instructor.OpenAIBatchResults[ConformancePerson](ctx, client, batch, requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r := results[0]; r.Err != nil || r.Value.Name != "Robby" || r.Response.Usage.TotalTokens != 15 {
+		t.Errorf("robby: %+v", r)
+	}
+	if r := results[1]; r.Err == nil || r.Response == nil {
+		t.Errorf("lucy: want a validation error, got %+v", r)
+	}
+	var apiErr *openai.APIError
+	if r := results[2]; r.Err == nil || !errors.As(r.Err, &apiErr) || apiErr.HTTPStatusCode != http.StatusInternalServerError {
+		t.Errorf("tom: want a server error, got %+v", r)
+	}
+	if r := results[3]; r.Err == nil {
+		t.Errorf("anna: want a missing result error, got %+v", r)
+	}
+
+	if err := instructor.RetryOpenAIBatch(ctx, client, requests, results); err != nil {
+		t.Fatal(err)
+	}
+	for k, r := range results {
+		if r.Err != nil {
+			t.Errorf("%s after retry: %v", r.CustomID, r.Err)
+		}
+		if want := "Retried"; k > 0 && r.Value.Name != want {
+			t.Errorf("%s after retry: %+v", r.CustomID, r.Value)
+		}
+	}
+}
+
+func TestRetryOpenAIBatch(t *testing.T) {
+	for _, mode := range []instructor.Mode{instructor.ModeJSON, instructor.ModeToolCall} {
+		t.Run(string(mode), func(t *testing.T) {
+			srv := newFakeOpenAIBatches(t, nil, nil)
+			cfg := openai.DefaultConfig("test")
+			cfg.BaseURL = srv.URL + "/v1"
+			client := instructor.FromOpenAI(openai.NewClientWithConfig(cfg), instructor.WithMode(mode))
+
+			requests := []instructor.OpenAIBatchRequest{{
+				CustomID: "robby",
+				Request: openai.ChatCompletionRequest{
+					Model:    openai.GPT4oMini,
+					Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Who is Robby?"}},
+				},
+			}}
+			ctx := context.Background()
+			if _, err := instructor.SubmitOpenAIBatch[ConformancePerson](ctx, client, requests); err != nil {
+				t.Fatal(err)
+			}
+
+			results := []instructor.OpenAIBatchResult[ConformancePerson]{{CustomID: "robby", Err: errors.New("expired")}}
+			if err := instructor.RetryOpenAIBatch(ctx, client, requests, results); err != nil || results[0].Err != nil {
+				t.Fatalf("err = %v, result = %+v", err, results[0])
+			}
+
+			// the retry adds the schema instructions to the request once
+			body := srv.chat.Request(0)
+			messages, _ := body["messages"].([]any)
+			tools, _ := body["tools"].([]any)
+			if len(messages)+len(tools) != 2 {
+				t.Errorf("retry request has %d messages and %d tools, want the schema once", len(messages), len(tools))
+			}
+
+			if results[0].Response == nil || results[0].Value.Name != "Retried" {
+				t.Errorf("result = %+v, want the retried response and value", results[0])
+			}
+
+			srv.chat.Fail(http.StatusBadRequest)
+			results[0].Err = errors.New("expired")
+			if err := instructor.RetryOpenAIBatch(ctx, client, requests, results); err != nil || results[0].Err == nil || results[0].Response != nil {
+				t.Errorf("err = %v, result = %+v, want a failed retry without response", err, results[0])
+			}
+
+			unknown := []instructor.OpenAIBatchResult[ConformancePerson]{{CustomID: "tom", Err: errors.New("expired")}}
+			if err := instructor.RetryOpenAIBatch(ctx, client, requests, unknown); err == nil {
+				t.Error("expected an error retrying results that do not match the requests")
+			}
+		})
+	}
+}
+
+func TestOpenAIBatchFile(t *testing.T) {
+	client := instructor.FromOpenAI(openai.NewClient("test"), instructor.WithMode(instructor.ModeToolCall))
+
+	file, err := instructor.NewOpenAIBatchFile[ConformancePerson](client, []instructor.OpenAIBatchRequest{
+		{CustomID: "a", Request: openai.ChatCompletionRequest{Model: openai.GPT4o}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(file.MarshalJSONL(), []byte(`"tools":[{"type":"function"`)) {
+		t.Errorf("batch line has no tools: %s", file.MarshalJSONL())
+	}
+
+	_, err = instructor.NewOpenAIBatchFile[ConformancePerson](client, []instructor.OpenAIBatchRequest{
+		{CustomID: "a", Request: openai.ChatCompletionRequest{Model: openai.GPT4o}},
+		{CustomID: "a", Request: openai.ChatCompletionRequest{Model: openai.GPT4o}},
+	})
+	if err == nil {
+		t.Error("expected an error for duplicate custom IDs")
+	}
+}
@@ -0,0 +1,87 @@
+package instructor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	anthropic "github.com/liushuangls/go-anthropic/v2"
+)
+
+func TestPromptCaching(t *testing.T) {
+	tests := []struct {
+		mode string
+		// cached returns the block of the request body marked for caching
+		cached func(body map[string]any) map[string]any
+	}{
+		{
+			mode: instructor.ModeToolCall,
+			cached: func(body map[string]any) map[string]any {
+				tools, _ := body["tools"].([]any)
+				tool, _ := tools[len(tools)-1].(map[string]any)
+				return tool
+			},
+		},
+		{
+			mode: instructor.ModeJSONSchema,
+			cached: func(body map[string]any) map[string]any {
+				system, _ := body["system"].([]any)
+				if len(system) != 2 || system[0].(map[string]any)["text"] != "You extract people." {
+					t.Errorf("system = %v, want the system prompt followed by the schema", body["system"])
+					return nil
+				}
+				block, _ := system[1].(map[string]any)
+				return block
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			srv := newAnthropicServer(t, `{"name": "Robby", "age": 22}`)
+			client := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")),
+				instructor.WithMode(tt.mode),
+				instructor.WithPromptCaching(),
+			)
+
+			var person ConformancePerson
+			var md instructor.Metadata
+			_, err := client.CreateMessages(instructor.WithMetadata(context.Background(), &md), anthropic.MessagesRequest{
+				Model:     anthropic.ModelClaude3Haiku20240307,
+				System:    "You extract people.",
+				Messages:  []anthropic.Message{anthropic.NewUserTextMessage("Robby is 22 years old.")},
+				MaxTokens: 500,
+			}, &person)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			block := tt.cached(srv.Request(0))
+			if control, _ := block["cache_control"].(map[string]any); control["type"] != "ephemeral" {
+				t.Errorf("block %v is not marked for caching", block)
+			}
+			if md.Usage.CachedInputTokens != 3 || md.Usage.CacheCreationTokens != 1 {
+				t.Errorf("usage = %+v, want the cache reads and writes", md.Usage)
+			}
+		})
+	}
+}
+
+func TestPromptCachingDisabled(t *testing.T) {
+	srv := newAnthropicServer(t, `{"name": "Robby", "age": 22}`)
+	client := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")), instructor.WithMode(instructor.ModeJSONSchema))
+
+	var person ConformancePerson
+	_, err := client.CreateMessages(context.Background(), anthropic.MessagesRequest{
+		Model:     anthropic.ModelClaude3Haiku20240307,
+		Messages:  []anthropic.Message{anthropic.NewUserTextMessage("Robby is 22 years old.")},
+		MaxTokens: 500,
+	}, &person)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := srv.Request(0)["system"].(string); !ok {
+		t.Errorf("system = %v, want a string without caching", srv.Request(0)["system"])
+	}
+}
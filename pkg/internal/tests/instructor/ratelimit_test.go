@@ -0,0 +1,183 @@
+package instructor_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+)
+
+// newHeaderServer returns a server answering its n-th request with the n-th
+// status and headers, and OK once they are exhausted.
+func newHeaderServer(t *testing.T, statuses []int, headers []map[string]string) *httptest.Server {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		if n < len(headers) {
+			for k, v := range headers[n] {
+				w.Header().Set(k, v)
+			}
+		}
+		if n < len(statuses) {
+			w.WriteHeader(statuses[n])
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(t *testing.T, client *http.Client, url string) {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+func TestRateLimiterRemainingRequests(t *testing.T) {
+	srv := newHeaderServer(t, nil, []map[string]string{{
+		"x-ratelimit-remaining-requests": "0",
+		"x-ratelimit-reset-requests":     "150ms",
+		"x-ratelimit-remaining-tokens":   "1000",
+		"x-ratelimit-reset-tokens":       "6m0s",
+	}})
+
+	limiter := instructor.NewRateLimiter(instructor.RateLimit{}, 0)
+	client := limiter.HTTPClient(nil)
+
+	get(t, client, srv.URL)
+
+	state := limiter.State()
+	if state.RemainingRequests != 0 || state.RemainingTokens != 1000 || state.InFlight != 0 {
+		t.Errorf("state = %+v", state)
+	}
+
+	start := time.Now()
+	get(t, client, srv.URL)
+	if elapsed := time.Since(start); elapsed < 120*time.Millisecond {
+		t.Errorf("request took %s, want it paused until the requests reset", elapsed)
+	}
+}
+
+func TestRateLimiterRetryAfter(t *testing.T) {
+	srv := newHeaderServer(t, []int{http.StatusTooManyRequests}, []map[string]string{{"retry-after-ms": "100"}})
+
+	limiter := instructor.NewRateLimiter(instructor.RateLimit{}, 8)
+	client := limiter.HTTPClient(nil)
+
+	get(t, client, srv.URL)
+
+	state := limiter.State()
+	if state.Concurrency != 1 || state.PausedUntil.IsZero() {
+		t.Errorf("state = %+v, want paused with concurrency cut to 1", state)
+	}
+
+	start := time.Now()
+	get(t, client, srv.URL)
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("request took %s, want it paused for retry-after", elapsed)
+	}
+	if c := limiter.State().Concurrency; c != 2 {
+		t.Errorf("concurrency = %d, want it grown back by 1", c)
+	}
+}
+
+func TestRateLimiterUnlimitedAgain(t *testing.T) {
+	srv := newHeaderServer(t, []int{http.StatusTooManyRequests}, []map[string]string{
+		{"retry-after-ms": "10"},
+		{"x-ratelimit-remaining-requests": "100", "x-ratelimit-reset-requests": "1m0s"},
+	})
+
+	limiter := instructor.NewRateLimiter(instructor.RateLimit{}, 0)
+	client := limiter.HTTPClient(nil)
+
+	get(t, client, srv.URL)
+	if c := limiter.State().Concurrency; c != 1 {
+		t.Errorf("concurrency = %d, want it cut to 1", c)
+	}
+
+	// the provider reports requests to spare
+	get(t, client, srv.URL)
+	if c := limiter.State().Concurrency; c != 0 {
+		t.Errorf("concurrency = %d, want it unlimited again", c)
+	}
+}
+
+func TestRateLimiterStaticWaitOutOfFlight(t *testing.T) {
+	srv := newHeaderServer(t, nil, nil)
+
+	limiter := instructor.NewRateLimiter(instructor.RateLimit{RequestsPerMinute: 1}, 1)
+	client := limiter.HTTPClient(nil)
+
+	get(t, client, srv.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+
+	done := make(chan error)
+	go func() {
+		_, err := client.Do(req)
+		done <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if n := limiter.State().InFlight; n != 0 {
+		t.Errorf("in flight = %d, want the request waiting for the static limit out of flight", n)
+	}
+	if err := <-done; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the request to wait for the next minute", err)
+	}
+}
+
+func TestRateLimiterAnthropicHeaders(t *testing.T) {
+	srv := newHeaderServer(t, nil, []map[string]string{{
+		"anthropic-ratelimit-tokens-remaining": "5",
+		"anthropic-ratelimit-tokens-reset":     time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	}})
+
+	limiter := instructor.NewRateLimiter(instructor.RateLimit{}, 0)
+	get(t, limiter.HTTPClient(nil), srv.URL)
+
+	if state := limiter.State(); state.RemainingTokens != 5 || state.RemainingRequests != -1 {
+		t.Errorf("state = %+v", state)
+	}
+}
+
+func TestRateLimiterTokenLimit(t *testing.T) {
+	// every response uses slightly more than the per minute limit, so the next
+	// request waits for the excess to refill, at 1000 tokens a second
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"usage": map[string]any{"prompt_tokens": 60_000, "completion_tokens": 100, "total_tokens": 60_100}})
+	}))
+	t.Cleanup(srv.Close)
+
+	limiter := instructor.NewRateLimiter(instructor.RateLimit{TokensPerMinute: 60_000}, 0)
+	client := limiter.HTTPClient(nil)
+
+	start := time.Now()
+	get(t, client, srv.URL)
+	get(t, client, srv.URL)
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("requests took %s, want the actual usage to delay the second one", elapsed)
+	}
+}
+
+func TestSharedRateLimiter(t *testing.T) {
+	a := instructor.SharedRateLimiter(instructor.ProviderOpenAI, "key-a")
+	if a != instructor.SharedRateLimiter(instructor.ProviderOpenAI, "key-a") {
+		t.Errorf("limiters of the same key differ")
+	}
+	if a == instructor.SharedRateLimiter(instructor.ProviderOpenAI, "key-b") || a == instructor.SharedRateLimiter(instructor.ProviderAnthropic, "key-a") {
+		t.Errorf("limiters of different keys are shared")
+	}
+}
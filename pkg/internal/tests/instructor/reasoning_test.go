@@ -0,0 +1,180 @@
+package instructor_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	anthropic "github.com/liushuangls/go-anthropic/v2"
+	openai "github.com/sashabaranov/go-openai"
+	"google.golang.org/genai"
+)
+
+func TestOpenAIReasoningModel(t *testing.T) {
+	srv := newOpenAIServer(t, `{"name": "Robby", "age": 22}`)
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = srv.URL + "/v1"
+	client := instructor.FromOpenAI(openai.NewClientWithConfig(cfg), instructor.WithMode(instructor.ModeJSON), instructor.WithConfidence())
+
+	var person ConformancePerson
+	var md instructor.Metadata
+	if _, err := client.Extract(instructor.WithMetadata(context.Background(), &md), neutralRequest(openai.O3Mini), &person); err != nil {
+		t.Fatal(err)
+	}
+	if person.Name != "Robby" || md.Usage.ReasoningTokens != 2 {
+		t.Errorf("person = %+v, usage = %+v", person, md.Usage)
+	}
+
+	body := srv.Request(0)
+	messages := body["messages"].([]any)
+	for _, k := range []int{0, 1} {
+		if role := messages[k].(map[string]any)["role"]; role != openai.ChatMessageRoleDeveloper {
+			t.Errorf("message %d has role %v, want developer", k, role)
+		}
+	}
+	if body["max_completion_tokens"] != float64(200) || body["max_tokens"] != nil || body["temperature"] != nil || body["logprobs"] != nil {
+		t.Errorf("request has parameters rejected by reasoning models: %v", body)
+	}
+}
+
+func TestOpenAIChatModel(t *testing.T) {
+	srv := newOpenAIServer(t, `{"name": "Robby", "age": 22}`)
+	client := instructor.FromOpenAI(openAIClient(srv), instructor.WithMode(instructor.ModeJSON))
+
+	var person ConformancePerson
+	if _, err := client.Extract(context.Background(), neutralRequest("gpt-5-chat-latest"), &person); err != nil {
+		t.Fatal(err)
+	}
+
+	body := srv.Request(0)
+	if role := body["messages"].([]any)[0].(map[string]any)["role"]; role != openai.ChatMessageRoleSystem {
+		t.Errorf("instructions have role %v, want system", role)
+	}
+	// go-openai only accepts the parameters of reasoning models for it
+	if body["max_completion_tokens"] != float64(200) || body["max_tokens"] != nil || body["temperature"] != nil {
+		t.Errorf("request has parameters rejected for GPT-5 models: %v", body)
+	}
+}
+
+func TestOpenAIReasoningModelSelfConsistency(t *testing.T) {
+	srv := newOpenAIServer(t, `{"name": "Robby", "age": 22}`)
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = srv.URL + "/v1"
+	client := instructor.FromOpenAI(openai.NewClientWithConfig(cfg),
+		instructor.WithMode(instructor.ModeJSON),
+		instructor.WithSelfConsistency(instructor.SelfConsistency{Samples: 3}),
+	)
+
+	var person ConformancePerson
+	var md instructor.Metadata
+	if _, err := client.Extract(instructor.WithMetadata(context.Background(), &md), neutralRequest("o1-mini"), &person); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Calls() != 3 || md.Samples != 3 {
+		t.Errorf("calls = %d, samples = %d, want an extraction per sample", srv.Calls(), md.Samples)
+	}
+	if n := srv.Request(0)["n"]; n != nil {
+		t.Errorf("n = %v, want none", n)
+	}
+	if role := srv.Request(0)["messages"].([]any)[0].(map[string]any)["role"]; role != openai.ChatMessageRoleUser {
+		t.Errorf("o1-mini instructions have role %v, want user", role)
+	}
+}
+
+// newAnthropicThinkingServer fakes the Anthropic messages API answering with
+// a thinking block, a redacted one and then the content of the mode.
+func newAnthropicThinkingServer(t *testing.T, output string, tool bool) *fakeServer {
+	return newFakeServer(t, []string{output}, func(f *fakeServer, w http.ResponseWriter, r *http.Request, body map[string]any, output string, truncated bool) {
+		content := map[string]any{"type": "text", "text": output}
+		if tool {
+			content = map[string]any{"type": "tool_use", "id": "toolu_1", "name": "ConformancePerson", "input": jsonObject(output)}
+		}
+		writeJSON(w, map[string]any{
+			"id":   "msg_1",
+			"type": "message",
+			"role": "assistant",
+			"content": []any{
+				map[string]any{"type": "thinking", "thinking": "Robby is the person.", "signature": "sig"},
+				map[string]any{"type": "redacted_thinking", "data": "encrypted"},
+				content,
+			},
+			"stop_reason": "end_turn",
+			"usage":       map[string]any{"input_tokens": 6, "output_tokens": 5},
+		})
+	})
+}
+
+func TestAnthropicThinking(t *testing.T) {
+	for _, mode := range []instructor.Mode{instructor.ModeToolCall, instructor.ModeJSON, instructor.ModeJSONSchema} {
+		t.Run(string(mode), func(t *testing.T) {
+			srv := newAnthropicThinkingServer(t, `{"name": "Robby", "age": 22}`, mode == instructor.ModeToolCall)
+			client := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")),
+				instructor.WithMode(mode),
+				instructor.WithReasoning(),
+			)
+
+			request := anthropicJSONRequest()
+			request.MaxTokens = 2048
+			request.Thinking = &anthropic.Thinking{Type: anthropic.ThinkingTypeEnabled, BudgetTokens: 1024}
+
+			var person ConformancePerson
+			var md instructor.Metadata
+			if _, err := client.CreateMessages(instructor.WithMetadata(context.Background(), &md), request, &person); err != nil {
+				t.Fatal(err)
+			}
+			if person.Name != "Robby" || person.Age != 22 {
+				t.Errorf("got %+v", person)
+			}
+			if md.Reasoning != "Robby is the person." {
+				t.Errorf("reasoning = %q", md.Reasoning)
+			}
+			if role, _ := lastMessage(srv.Request(0)); role != "user" {
+				t.Error("extended thinking request was prefilled")
+			}
+		})
+	}
+}
+
+func TestGeminiThoughtParts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"candidates": []any{map[string]any{
+				"content": map[string]any{"role": "model", "parts": []any{
+					map[string]any{"text": "The user mentions {Robby}.", "thought": true},
+					map[string]any{"text": `{"name": "Robby", "age": 22}`},
+				}},
+				"finishReason": "STOP",
+			}},
+			"usageMetadata": map[string]any{"promptTokenCount": 10, "candidatesTokenCount": 3, "thoughtsTokenCount": 2, "totalTokenCount": 15},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	genaiClient, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: srv.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mode := range []instructor.Mode{instructor.ModeJSON, instructor.ModeJSONSchema} {
+		client := instructor.FromGoogle(genaiClient, instructor.WithMode(mode), instructor.WithReasoning())
+
+		var person ConformancePerson
+		var md instructor.Metadata
+		_, err := client.CreateChatCompletion(instructor.WithMetadata(context.Background(), &md), instructor.GoogleRequest{
+			Model:    "gemini-2.5-flash",
+			Contents: []*genai.Content{genai.NewContentFromText("Robby is 22 years old.", genai.RoleUser)},
+		}, &person)
+		if err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		if person.Name != "Robby" || md.Reasoning != "The user mentions {Robby}." || md.Usage.ReasoningTokens != 2 {
+			t.Errorf("%s: person = %+v, metadata = %+v", mode, person, md)
+		}
+	}
+}
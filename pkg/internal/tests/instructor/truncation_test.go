@@ -0,0 +1,122 @@
+package instructor_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	anthropic "github.com/liushuangls/go-anthropic/v2"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestTruncationContinue(t *testing.T) {
+	srv := newOpenAIServer(t, `{"name": "Ro`, `bby", "ag`, `e": 22}`).Truncate()
+	client := instructor.FromOpenAI(openAIClient(srv),
+		instructor.WithMode(instructor.ModeJSONSchema),
+		instructor.WithTruncation(instructor.Truncation{}),
+	)
+
+	var person ConformancePerson
+	var md instructor.Metadata
+	if _, err := client.Extract(instructor.WithMetadata(context.Background(), &md), neutralRequest(openai.GPT4o), &person); err != nil {
+		t.Fatal(err)
+	}
+	if person.Name != "Robby" || person.Age != 22 {
+		t.Errorf("got %+v", person)
+	}
+	if srv.Calls() != 3 || md.Attempts != 1 || md.Usage.TotalTokens != 45 {
+		t.Errorf("calls = %d, metadata = %+v, want one attempt of 3 calls", srv.Calls(), md)
+	}
+
+	messages := srv.Request(2)["messages"].([]any)
+	partial := messages[len(messages)-2].(map[string]any)
+	if partial["role"] != "assistant" || partial["content"] != `{"name": "Robby", "ag` {
+		t.Errorf("continuation is not sent the stitched output: %v", partial)
+	}
+}
+
+func TestTruncationContinueAnthropic(t *testing.T) {
+	srv := newAnthropicServer(t, `"name": "Ro`, `bby", "age": 22}`).Truncate()
+	client := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")),
+		instructor.WithMode(instructor.ModeJSON),
+		instructor.WithTruncation(instructor.Truncation{Strategy: instructor.TruncationContinue}),
+	)
+
+	var person ConformancePerson
+	if _, err := client.CreateMessages(context.Background(), anthropicJSONRequest(), &person); err != nil {
+		t.Fatal(err)
+	}
+	if person.Name != "Robby" || person.Age != 22 {
+		t.Errorf("got %+v", person)
+	}
+	if role, text := lastMessage(srv.Request(1)); role != "assistant" || text != `{"name": "Ro` {
+		t.Errorf("continuation prefill = %s %q, want the output so far", role, text)
+	}
+}
+
+func TestTruncationContinueWhitespace(t *testing.T) {
+	// a prefill cannot end with whitespace, which the continuation may repeat
+	for _, rest := range []string{`Lee", "age": 22}`, ` Lee", "age": 22}`} {
+		srv := newAnthropicServer(t, `"name": "Robby `, rest).Truncate()
+		client := instructor.FromAnthropic(anthropic.NewClient("test", anthropic.WithBaseURL(srv.URL+"/v1")),
+			instructor.WithMode(instructor.ModeJSON),
+			instructor.WithTruncation(instructor.Truncation{}),
+		)
+
+		var person ConformancePerson
+		if _, err := client.CreateMessages(context.Background(), anthropicJSONRequest(), &person); err != nil {
+			t.Fatal(err)
+		}
+		if person.Name != "Robby Lee" {
+			t.Errorf("continued with %q: name = %q, want the space kept", rest, person.Name)
+		}
+		if _, text := lastMessage(srv.Request(1)); text != `{"name": "Robby` {
+			t.Errorf("continuation prefill = %q, want it without the trailing space", text)
+		}
+	}
+}
+
+func TestTruncationRaiseLimit(t *testing.T) {
+	for _, strategy := range []instructor.TruncationStrategy{instructor.TruncationRaiseLimit, instructor.TruncationContinue} {
+		t.Run(string(strategy), func(t *testing.T) {
+			// JSON mode cannot be continued, so both strategies raise the limit
+			srv := newOpenAIServer(t, `{"name": "Ro`, `{"name": "Robby", "age": 22}`).Truncate()
+			client := instructor.FromOpenAI(openAIClient(srv),
+				instructor.WithMode(instructor.ModeJSON),
+				instructor.WithTruncation(instructor.Truncation{Strategy: strategy, MaxTokens: 300}),
+			)
+
+			var person ConformancePerson
+			var md instructor.Metadata
+			if _, err := client.Extract(instructor.WithMetadata(context.Background(), &md), neutralRequest(openai.GPT4o), &person); err != nil {
+				t.Fatal(err)
+			}
+			if person.Name != "Robby" || md.Attempts != 2 {
+				t.Errorf("person = %+v, attempts = %d", person, md.Attempts)
+			}
+			if limit := srv.Request(1)["max_tokens"]; limit != float64(300) {
+				t.Errorf("max_tokens = %v, want the raised limit capped at 300", limit)
+			}
+		})
+	}
+}
+
+func TestTruncationFail(t *testing.T) {
+	srv := newOpenAIServer(t, `{"name": "Ro`, `{"name": "Robby", "age": 22}`).Truncate()
+	client := instructor.FromOpenAI(openAIClient(srv),
+		instructor.WithMode(instructor.ModeJSON),
+		instructor.WithTruncation(instructor.Truncation{Strategy: instructor.TruncationFail}),
+	)
+
+	var person ConformancePerson
+	_, err := client.Extract(context.Background(), neutralRequest(openai.GPT4o), &person)
+
+	var truncated *instructor.TruncatedOutputError
+	if !errors.As(err, &truncated) || truncated.Output != `{"name": "Ro` || truncated.FinishReason != "length" {
+		t.Fatalf("err = %v, want a TruncatedOutputError with the partial output", err)
+	}
+	if srv.Calls() != 1 {
+		t.Errorf("calls = %d, want 1", srv.Calls())
+	}
+}
@@ -0,0 +1,41 @@
+package instructor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestUsageAttempts(t *testing.T) {
+	client := instructor.FromOpenAI(
+		newFakeOpenAI(t, `not json`, `{"name": "Robby", "age": 22}`),
+		instructor.WithMode(instructor.ModeJSON),
+	)
+
+	var md instructor.Metadata
+	var person Person
+	resp, err := client.CreateChatCompletion(instructor.WithMetadata(context.Background(), &md), openai.ChatCompletionRequest{
+		Model:    openai.GPT4o,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Robby is 22 years old."}},
+	}, &person)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(md.Usage.Attempts) != 2 {
+		t.Fatalf("attempts: got %d, want 2", len(md.Usage.Attempts))
+	}
+	for idx, attempt := range md.Usage.Attempts {
+		if attempt.InputTokens != 10 || attempt.OutputTokens != 5 || attempt.TotalTokens != 15 {
+			t.Errorf("attempt %d: got %+v", idx, attempt)
+		}
+	}
+	if md.Usage.TotalTokens != 30 {
+		t.Errorf("total tokens: got %d, want 30", md.Usage.TotalTokens)
+	}
+	if resp.Usage.TotalTokens != md.Usage.TotalTokens {
+		t.Errorf("native total tokens: got %d, want %d", resp.Usage.TotalTokens, md.Usage.TotalTokens)
+	}
+}
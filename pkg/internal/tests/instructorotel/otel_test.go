@@ -0,0 +1,234 @@
+package instructorotel_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	"github.com/instructor-ai/instructor-go/pkg/instructor/instructorotel"
+	openai "github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type Person struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func newFakeOpenAI(t *testing.T, outputs ...string) *openai.Client {
+	t.Helper()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		output := outputs[min(n, len(outputs)-1)]
+
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			chunk, _ := json.Marshal(openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{{
+				Delta: openai.ChatCompletionStreamChoiceDelta{Content: output},
+			}}})
+			usage, _ := json.Marshal(openai.ChatCompletionStreamResponse{
+				Usage: &openai.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+			})
+			fmt.Fprintf(w, "data: %s\n\ndata: %s\n\ndata: [DONE]\n\n", chunk, usage)
+			return
+		}
+
+		resp := openai.ChatCompletionResponse{
+			Model: "gpt-4o",
+			Choices: []openai.ChatCompletionChoice{{
+				Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: output},
+				FinishReason: openai.FinishReasonStop,
+			}},
+			Usage: openai.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = srv.URL + "/v1"
+	return openai.NewClientWithConfig(cfg)
+}
+
+func TestTelemetry(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	client := instructor.FromOpenAI(
+		newFakeOpenAI(t, `not json`, `{"name": "Robby", "age": 22}`),
+		instructor.WithMode(instructor.ModeJSON),
+		instructorotel.WithTelemetry(
+			instructorotel.WithTracerProvider(tp),
+			instructorotel.WithMeterProvider(mp),
+		),
+	)
+
+	var person Person
+	_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT4o,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Robby is 22 years old."}},
+	}, &person)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans (1 extraction, 2 attempts), got %d", len(spans))
+	}
+
+	extraction := spans[len(spans)-1]
+	if extraction.Name != "extract Person" {
+		t.Errorf("unexpected extraction span name %q", extraction.Name)
+	}
+	for _, s := range spans[:2] {
+		if s.Parent.SpanID() != extraction.SpanContext.SpanID() {
+			t.Errorf("attempt span %q is not a child of the extraction span", s.Name)
+		}
+	}
+
+	want := map[attribute.Key]attribute.Value{
+		"gen_ai.system":              attribute.StringValue("openai"),
+		"gen_ai.request.model":       attribute.StringValue(openai.GPT4o),
+		"gen_ai.usage.input_tokens":  attribute.IntValue(20),
+		"gen_ai.usage.output_tokens": attribute.IntValue(10),
+		"instructor.mode":            attribute.StringValue(instructor.ModeJSON),
+		"instructor.schema.name":     attribute.StringValue("Person"),
+		"instructor.retries":         attribute.IntValue(1),
+	}
+	got := map[attribute.Key]attribute.Value{}
+	for _, kv := range extraction.Attributes {
+		got[kv.Key] = kv.Value
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("attribute %s: got %v, want %v", k, got[k].Emit(), v.Emit())
+		}
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+
+	failures := int64(0)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "instructor.validation.failures" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				failures += dp.Value
+			}
+		}
+	}
+	if failures != 1 {
+		t.Errorf("expected 1 validation failure, got %d", failures)
+	}
+}
+
+func TestTelemetryErrorType(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	client := instructor.FromOpenAI(
+		newFakeOpenAI(t, `{"name": "Robby", "age": 22}`),
+		instructor.WithMode(instructor.ModeJSON),
+		instructorotel.WithTelemetry(instructorotel.WithMeterProvider(mp)),
+	)
+
+	// a dollar budget refuses models without a price
+	ctx := instructor.WithBudget(context.Background(), instructor.NewBudget(0, 5))
+
+	var person Person
+	_, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{Model: "my-fine-tune"}, &person)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "instructor.extraction.duration" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Histogram[float64]).DataPoints {
+				v, _ := dp.Attributes.Value("error.type")
+				if v.AsString() != "*instructor.UnpricedModelError" {
+					t.Errorf("error.type = %q, want the type of the cause", v.AsString())
+				}
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Error("no extraction duration recorded")
+	}
+}
+
+func TestTelemetryStreamTokenUsage(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	client := instructor.FromOpenAI(
+		newFakeOpenAI(t, `{"items": [{"name": "Robby", "age": 22}]}`),
+		instructor.WithMode(instructor.ModeJSON),
+		instructorotel.WithTelemetry(instructorotel.WithMeterProvider(mp)),
+	)
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:         openai.GPT4o,
+		Messages:      []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Robby is 22 years old."}},
+		Stream:        true,
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+	}, *new(Person))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range stream {
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+
+	tokens := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "gen_ai.client.token.usage" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Histogram[int64]).DataPoints {
+				v, _ := dp.Attributes.Value("gen_ai.token.type")
+				tokens[v.AsString()] += dp.Sum
+			}
+		}
+	}
+	if tokens["input"] != 10 || tokens["output"] != 5 {
+		t.Errorf("token usage = %v, want the 10 input and 5 output tokens of the stream", tokens)
+	}
+}